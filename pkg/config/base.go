@@ -16,9 +16,12 @@ package config
 
 import (
 	"os"
+	"path"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/livekit/egress/pkg/errors"
 	"github.com/livekit/protocol/logger"
 	"github.com/livekit/protocol/redis"
 	lksdk "github.com/livekit/server-sdk-go/v2"
@@ -34,13 +37,18 @@ type BaseConfig struct {
 	WsUrl     string             `yaml:"ws_url"`     // (env LIVEKIT_WS_URL)
 
 	// optional
-	Logging             *logger.Config          `yaml:"logging"`               // logging config
-	TemplateBase        string                  `yaml:"template_base"`         // custom template base url
-	BackupStorage       string                  `yaml:"backup_storage"`        // backup file location for failed uploads
-	ClusterID           string                  `yaml:"cluster_id"`            // cluster this instance belongs to
-	EnableChromeSandbox bool                    `yaml:"enable_chrome_sandbox"` // enable Chrome sandbox, requires extra docker configuration
-	StorageConfig       `yaml:",inline"`        // upload config (S3, Azure, GCP, or AliOSS)
-	SessionLimits       `yaml:"session_limits"` // session duration limits
+	Logging                *logger.Config          `yaml:"logging"`                   // logging config
+	TemplateBase           string                  `yaml:"template_base"`             // custom template base url
+	BackupStorage          string                  `yaml:"backup_storage"`            // backup file location for failed uploads
+	ClusterID              string                  `yaml:"cluster_id"`                // cluster this instance belongs to
+	EnableChromeSandbox    bool                    `yaml:"enable_chrome_sandbox"`     // enable Chrome sandbox, requires extra docker configuration
+	EnableSDKRoomComposite bool                    `yaml:"enable_sdk_room_composite"` // record audio-only and native-* layout room composites without Chrome
+	DefaultVideoCodec      string                  `yaml:"default_video_codec"`       // h264, h265, vp8, vp9 or av1, used when a request does not specify a video codec
+	Slate                  *SlateConfig            `yaml:"slate"`                     // shown instead of black frames while sdk egress video is muted or missing, or chrome is relaunched
+	ChromeRecovery         *ChromeRecoveryConfig   `yaml:"chrome_recovery"`           // chrome launch retries and relaunches after a crash during web egress
+	ClipsDir               string                  `yaml:"clips_dir"`                 // absolute path, local intro and outro clips are only read from this directory
+	StorageConfig          `yaml:",inline"`        // upload config (S3, Azure, GCP, or AliOSS)
+	SessionLimits          `yaml:"session_limits"` // session duration limits
 
 	// dev/debugging
 	Insecure bool        `yaml:"insecure"` // allow chrome to connect to an insecure websocket
@@ -106,6 +114,55 @@ type SessionLimits struct {
 	ImageOutputMaxDuration   time.Duration `yaml:"image_output_max_duration"`
 }
 
+type TextOverlay struct {
+	Text   string `yaml:"text"`   // supports {room_name}, {room_id}, {publisher_identity} and {egress_id}
+	HAlign string `yaml:"halign"` // left, center or right (default left)
+	VAlign string `yaml:"valign"` // top, center or bottom (default top)
+	Font   string `yaml:"font"`   // pango font description, e.g. Sans Bold 24
+}
+
+type SlateConfig struct {
+	Image      string       `yaml:"image"`      // png or jpeg filepath
+	Video      string       `yaml:"video"`      // short video filepath, looped
+	Text       *TextOverlay `yaml:"text"`       // text card, centered by default
+	Background string       `yaml:"background"` // text card color as RRGGBB (default 000000)
+}
+
+func (c *SlateConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+
+	var count int
+	for _, set := range []bool{c.Image != "", c.Video != "", c.Text != nil} {
+		if set {
+			count++
+		}
+	}
+	if count != 1 {
+		// exactly one of image, video or text
+		return errors.ErrInvalidInput("slate")
+	}
+
+	switch strings.ToLower(path.Ext(c.Image)) {
+	case "", ".png", ".jpg", ".jpeg":
+	default:
+		return errors.ErrInvalidInput("slate.image")
+	}
+	if bg := strings.TrimPrefix(c.Background, "#"); bg != "" {
+		if _, err := strconv.ParseUint(bg, 16, 32); err != nil || len(bg) != 6 {
+			return errors.ErrInvalidInput("slate.background")
+		}
+	}
+	return nil
+}
+
+// GetBackgroundColor returns the text card color as ARGB
+func (c *SlateConfig) GetBackgroundColor() uint32 {
+	rgb, _ := strconv.ParseUint(strings.TrimPrefix(c.Background, "#"), 16, 32)
+	return 0xff000000 | uint32(rgb)
+}
+
 func (c *BaseConfig) initLogger(values ...interface{}) error {
 	if c.LogLevel != "" {
 		logger.Warnw("log_level deprecated. use logging instead", nil)
@@ -0,0 +1,103 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	voiceTag    = regexp.MustCompile(`^<v(?:\.[^ >]*)? ([^>]*)>`)
+	captionTags = regexp.MustCompile(`</?[^>]*>`)
+	captionText = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&nbsp;", " ")
+)
+
+// ParseCaptions reads cues from a WebVTT or SRT file. Voice tags are used as the cue identity
+func ParseCaptions(b []byte) ([]DataRecord, error) {
+	text := strings.ReplaceAll(strings.TrimPrefix(string(b), "\uFEFF"), "\r\n", "\n")
+
+	var cues []DataRecord
+	for _, block := range strings.Split(text, "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+
+		// the timing line can follow a cue identifier or srt index
+		i := 0
+		for i < len(lines) && !strings.Contains(lines[i], "-->") {
+			i++
+		}
+		if i == len(lines) || strings.HasPrefix(lines[0], "NOTE") {
+			continue
+		}
+
+		start, end, err := parseCueTiming(lines[i])
+		if err != nil {
+			return nil, err
+		}
+
+		cue := DataRecord{Start: start, End: end}
+		body := strings.Join(lines[i+1:], "\n")
+		if m := voiceTag.FindStringSubmatch(body); m != nil {
+			cue.Identity = m[1]
+		}
+		cue.Text = captionText.Replace(captionTags.ReplaceAllString(body, ""))
+		cues = append(cues, cue)
+	}
+
+	return cues, nil
+}
+
+// parseCueTiming parses "00:01.000 --> 00:02.500 align:start", or "00:00:01,000 --> 00:00:02,500"
+func parseCueTiming(line string) (time.Duration, time.Duration, error) {
+	from, to, _ := strings.Cut(line, "-->")
+	fields := strings.Fields(to)
+	if len(fields) == 0 {
+		return 0, 0, fmt.Errorf("invalid cue timing %q", line)
+	}
+
+	start, err := parseCueTimestamp(strings.TrimSpace(from))
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseCueTimestamp(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+func parseCueTimestamp(ts string) (time.Duration, error) {
+	parts := strings.Split(strings.Replace(ts, ",", ".", 1), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid cue timestamp %q", ts)
+	}
+
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cue timestamp %q", ts)
+	}
+	d := time.Duration(seconds * float64(time.Second))
+	for i, unit := range []time.Duration{time.Minute, time.Hour}[:len(parts)-1] {
+		v, err := strconv.Atoi(parts[len(parts)-2-i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid cue timestamp %q", ts)
+		}
+		d += time.Duration(v) * unit
+	}
+	return d.Round(time.Millisecond), nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCaptions(t *testing.T) {
+	expected := []DataRecord{
+		{Start: time.Second, End: time.Millisecond * 2500, Identity: "Alice", Text: "Hello & welcome"},
+		{Start: time.Hour + time.Second*3, End: time.Hour + time.Second*4, Text: "two\nlines"},
+	}
+
+	vtt := "WEBVTT\n\nNOTE skipped --> comment\n\n" +
+		"intro\n00:01.000 --> 00:02.500 align:start\n<v Alice>Hello &amp; <b>welcome</b></v>\n\n" +
+		"01:00:03.000 --> 01:00:04.000\ntwo\nlines\n"
+	cues, err := ParseCaptions([]byte(vtt))
+	require.NoError(t, err)
+	require.Equal(t, expected, cues)
+
+	srt := "1\r\n00:00:01,000 --> 00:00:02,500\r\n<v Alice>Hello &amp; welcome\r\n\r\n" +
+		"2\r\n01:00:03,000 --> 01:00:04,000\r\ntwo\r\nlines\r\n"
+	cues, err = ParseCaptions([]byte(srt))
+	require.NoError(t, err)
+	require.Equal(t, expected, cues)
+
+	_, err = ParseCaptions([]byte("WEBVTT\n\n00:xx.000 --> 00:02.000\ninvalid\n"))
+	require.Error(t, err)
+}
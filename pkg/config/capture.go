@@ -0,0 +1,44 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+// CaptureRegion is the part of the screen recorded by a web egress
+type CaptureRegion struct {
+	X      int32
+	Y      int32
+	Width  int32
+	Height int32
+}
+
+// SetCaptureRegion clips the region to the screen. It is ignored if nothing is left, or if it covers the whole screen.
+func (p *PipelineConfig) SetCaptureRegion(x, y, width, height int32) {
+	p.CaptureRegion = nil
+
+	x, y = max(x, 0), max(y, 0)
+
+	// raw video sizes must be even
+	width = min(width, p.Width-x) &^ 1
+	height = min(height, p.Height-y) &^ 1
+	if width <= 0 || height <= 0 || (width == p.Width && height == p.Height) {
+		return
+	}
+
+	p.CaptureRegion = &CaptureRegion{
+		X:      x,
+		Y:      y,
+		Width:  width,
+		Height: height,
+	}
+}
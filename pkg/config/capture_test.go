@@ -0,0 +1,38 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureRegion(t *testing.T) {
+	p := &PipelineConfig{VideoConfig: VideoConfig{Width: 1920, Height: 1080}}
+
+	p.SetCaptureRegion(100, 50, 641, 361)
+	require.Equal(t, &CaptureRegion{X: 100, Y: 50, Width: 640, Height: 360}, p.CaptureRegion)
+
+	p.SetCaptureRegion(0, 1000, 4000, 200)
+	require.Equal(t, &CaptureRegion{X: 0, Y: 1000, Width: 1920, Height: 80}, p.CaptureRegion)
+
+	p.SetCaptureRegion(0, 0, 1920, 1080)
+	require.Nil(t, p.CaptureRegion)
+
+	require.Error(t, (&WebConfig{Capture: &WebCaptureConfig{Width: 640}}).validate())
+	require.Error(t, (&WebConfig{Capture: &WebCaptureConfig{X: -1, Width: 640, Height: 360}}).validate())
+	require.NoError(t, (&WebConfig{Capture: &WebCaptureConfig{Selector: "#player", Fill: true}}).validate())
+}
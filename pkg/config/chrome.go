@@ -0,0 +1,73 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"time"
+
+	"github.com/livekit/egress/pkg/errors"
+)
+
+// WebVideoTrackID is muted while chrome is relaunched, switching web egress video to the slate
+const WebVideoTrackID = "web_video"
+
+type ChromeRecoveryConfig struct {
+	LaunchAttempts   int           `yaml:"launch_attempts"`    // attempts to load the page when the egress starts (default 5)
+	LaunchRetryDelay time.Duration `yaml:"launch_retry_delay"` // delay between launch attempts (default 10s)
+	MaxRelaunches    int           `yaml:"max_relaunches"`     // relaunches after a crash, -1 to end the egress instead (default 3)
+}
+
+func (c *ChromeRecoveryConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.LaunchAttempts < 0 {
+		return errors.ErrInvalidInput("chrome_recovery.launch_attempts")
+	}
+	if c.LaunchRetryDelay < 0 {
+		return errors.ErrInvalidInput("chrome_recovery.launch_retry_delay")
+	}
+	if c.MaxRelaunches < -1 {
+		return errors.ErrInvalidInput("chrome_recovery.max_relaunches")
+	}
+	return nil
+}
+
+// GetChromeRecovery returns the recovery config with defaults applied
+func (c *BaseConfig) GetChromeRecovery() *ChromeRecoveryConfig {
+	conf := &ChromeRecoveryConfig{}
+	if c.ChromeRecovery != nil {
+		*conf = *c.ChromeRecovery
+	}
+	if conf.LaunchAttempts == 0 {
+		conf.LaunchAttempts = 5
+	}
+	if conf.LaunchRetryDelay == 0 {
+		conf.LaunchRetryDelay = time.Second * 10
+	}
+	switch conf.MaxRelaunches {
+	case 0:
+		conf.MaxRelaunches = 3
+	case -1:
+		conf.MaxRelaunches = 0
+	}
+	return conf
+}
+
+// ShowsSlateOnRelaunch returns true if web video switches to the slate while chrome is relaunched.
+// Without chrome_recovery, the web input is linked directly and the reloading page is recorded
+func (c *BaseConfig) ShowsSlateOnRelaunch() bool {
+	return c.ChromeRecovery != nil && c.GetChromeRecovery().MaxRelaunches > 0
+}
@@ -0,0 +1,38 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChromeRecovery(t *testing.T) {
+	c := &BaseConfig{}
+	require.Equal(t, &ChromeRecoveryConfig{LaunchAttempts: 5, LaunchRetryDelay: time.Second * 10, MaxRelaunches: 3}, c.GetChromeRecovery())
+
+	c.ChromeRecovery = &ChromeRecoveryConfig{LaunchAttempts: 2, MaxRelaunches: -1}
+	require.NoError(t, c.ChromeRecovery.validate())
+	require.Equal(t, 0, c.GetChromeRecovery().MaxRelaunches)
+	require.Equal(t, -1, c.ChromeRecovery.MaxRelaunches)
+	require.Error(t, (&ChromeRecoveryConfig{MaxRelaunches: -2}).validate())
+
+	require.False(t, c.ShowsSlateOnRelaunch())
+	c.ChromeRecovery = &ChromeRecoveryConfig{}
+	require.True(t, c.ShowsSlateOnRelaunch())
+	require.False(t, (&BaseConfig{}).ShowsSlateOnRelaunch())
+}
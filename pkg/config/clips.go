@@ -0,0 +1,84 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/types"
+)
+
+// GetClipURIs returns the intro and outro uris for this egress, empty if not configured
+func (p *PipelineConfig) GetClipURIs() (string, string, error) {
+	return p.getClipURIs(map[string]string{
+		"{room_name}": p.Info.RoomName,
+		"{egress_id}": p.Info.EgressId,
+	})
+}
+
+func (p *PipelineConfig) getClipURIs(replacements map[string]string) (string, string, error) {
+	intro, ok := getClipURI(p.IntroOutro.Intro, p.ClipsDir, replacements)
+	if !ok {
+		return "", "", errors.ErrInvalidInput(optionsParam + ".intro_outro.intro")
+	}
+	outro, ok := getClipURI(p.IntroOutro.Outro, p.ClipsDir, replacements)
+	if !ok {
+		return "", "", errors.ErrInvalidInput(optionsParam + ".intro_outro.outro")
+	}
+	return intro, outro, nil
+}
+
+// validateIntroOutro rejects intro and outro for flac files, which can't be joined without rewriting
+// the STREAMINFO header, so the sample count and duration would be wrong
+func (p *PipelineConfig) validateIntroOutro() error {
+	if p.IntroOutro.Intro == "" && p.IntroOutro.Outro == "" {
+		return nil
+	}
+	if o := p.GetFileConfig(); o != nil && o.OutputType == types.OutputTypeFLAC {
+		return errors.ErrNotSupported("flac intro and outro")
+	}
+	return nil
+}
+
+// getClipURI returns http(s) urls as they are, and converts filepaths to file uris under clipsDir.
+// Local clips are rejected without a clips dir, and absolute paths, other schemes and parent directories are
+// rejected once templates are replaced, so requests can't read other files on the host
+func getClipURI(location, clipsDir string, replacements map[string]string) (string, bool) {
+	switch {
+	case location == "":
+		return "", true
+
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		escaped := make(map[string]string, len(replacements))
+		for template, value := range replacements {
+			escaped[template] = url.PathEscape(value)
+		}
+		return stringReplace(location, escaped), true
+
+	case clipsDir == "":
+		return "", false
+	}
+
+	location = stringReplace(location, replacements)
+	if path.IsAbs(location) || strings.Contains(location, "://") || slices.Contains(strings.Split(location, "/"), "..") {
+		return "", false
+	}
+	u := &url.URL{Scheme: "file", Path: path.Join(clipsDir, location)}
+	return u.String(), true
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/egress/pkg/info"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+)
+
+func TestClipURIs(t *testing.T) {
+	p := &PipelineConfig{
+		BaseConfig: BaseConfig{ClipsDir: "/clips"},
+		Info:       &info.EgressInfo{EgressId: "EG_1", RoomName: "my room"},
+	}
+	p.IntroOutro = IntroOutroConfig{
+		Intro: "https://cdn.example.com/{room_name}/intro.mp4",
+		Outro: "{egress_id} outro.mp4",
+	}
+	require.NoError(t, p.RequestOptions.validate())
+
+	intro, outro, err := p.GetClipURIs()
+	require.NoError(t, err)
+	require.Equal(t, "https://cdn.example.com/my%20room/intro.mp4", intro)
+	require.Equal(t, "file:///clips/EG_1%20outro.mp4", outro)
+
+	p.IntroOutro = IntroOutroConfig{Outro: "rooms/outro.mp4"}
+	intro, outro, err = p.GetClipURIs()
+	require.NoError(t, err)
+	require.Empty(t, intro)
+	require.Equal(t, "file:///clips/rooms/outro.mp4", outro)
+
+	// local clips need a clips dir
+	p.ClipsDir = ""
+	_, _, err = p.GetClipURIs()
+	require.Error(t, err)
+	p.ClipsDir = "/clips"
+
+	for _, location := range []string{
+		"/etc/intro.mp4",
+		"file:///etc/intro.mp4",
+		"ftp://example.com/intro.mp4",
+		"../intro.mp4",
+		"rooms/../../intro.mp4",
+	} {
+		p.IntroOutro = IntroOutroConfig{Intro: location}
+		_, _, err = p.GetClipURIs()
+		require.Error(t, err, location)
+	}
+
+	// templates can't add parent directories or absolute paths
+	p.IntroOutro = IntroOutroConfig{Intro: "{room_name}/intro.mp4"}
+	for _, roomName := range []string{"..", "../etc", "/etc"} {
+		p.Info.RoomName = roomName
+		_, _, err = p.GetClipURIs()
+		require.Error(t, err, roomName)
+	}
+}
+
+func TestIntroOutroFLAC(t *testing.T) {
+	conf := &ServiceConfig{
+		BaseConfig: BaseConfig{
+			ApiKey:    "key",
+			ApiSecret: "secret",
+			WsUrl:     "wss://localhost:7880",
+		},
+	}
+
+	options := "#lk_egress=" + url.PathEscape(`{"intro_outro":{"intro":"https://cdn.example.com/intro.mp4"}}`)
+	for _, test := range []struct {
+		filepath    string
+		expectedErr bool
+	}{
+		{filepath: "recording.ogg"},
+		{filepath: "recording.flac", expectedErr: true},
+	} {
+		_, err := GetValidatedPipelineConfig(conf, &rpc.StartEgressRequest{
+			EgressId: "egress_ID",
+			Request: &rpc.StartEgressRequest_TrackComposite{
+				TrackComposite: &livekit.TrackCompositeEgressRequest{
+					RoomName:     "room",
+					AudioTrackId: "audio_track",
+					FileOutputs:  []*livekit.EncodedFileOutput{{Filepath: test.filepath + options}},
+				},
+			},
+		})
+		if test.expectedErr {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+	}
+}
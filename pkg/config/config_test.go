@@ -15,13 +15,18 @@
 package config
 
 import (
+	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/livekit/egress/pkg/info"
+	"github.com/livekit/egress/pkg/types"
 	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+	lksdk "github.com/livekit/server-sdk-go/v2"
 )
 
 func TestSegmentNaming(t *testing.T) {
@@ -93,3 +98,209 @@ func TestSegmentNaming(t *testing.T) {
 		require.Equal(t, test.expectedSegmentPrefix, o.SegmentPrefix)
 	}
 }
+
+func TestSDKRoomComposite(t *testing.T) {
+	conf := &ServiceConfig{
+		BaseConfig: BaseConfig{
+			ApiKey:                 "key",
+			ApiSecret:              "secret",
+			WsUrl:                  "wss://localhost:7880",
+			TemplateBase:           "http://localhost:7980/",
+			EnableSDKRoomComposite: true,
+		},
+	}
+
+	for _, test := range []struct {
+		audioOnly          bool
+		layout             string
+		customBaseUrl      string
+		fileType           livekit.EncodedFileType
+		expectedSourceType types.SourceType
+		expectedLayout     string
+	}{
+		{audioOnly: true, fileType: livekit.EncodedFileType_OGG, expectedSourceType: types.SourceTypeSDK},
+		{layout: "native-grid-dark", fileType: livekit.EncodedFileType_MP4, expectedSourceType: types.SourceTypeSDK, expectedLayout: types.LayoutGrid},
+		{layout: "native-single-speaker", fileType: livekit.EncodedFileType_MP4, expectedSourceType: types.SourceTypeSDK, expectedLayout: types.LayoutSingleSpeaker},
+		{layout: "", fileType: livekit.EncodedFileType_MP4, expectedSourceType: types.SourceTypeWeb, expectedLayout: ""},
+		{layout: "grid", fileType: livekit.EncodedFileType_MP4, expectedSourceType: types.SourceTypeWeb, expectedLayout: "grid"},
+		{layout: "native-custom", fileType: livekit.EncodedFileType_MP4, expectedSourceType: types.SourceTypeWeb, expectedLayout: "custom"},
+		{layout: "native-grid", customBaseUrl: "https://example.com", fileType: livekit.EncodedFileType_MP4, expectedSourceType: types.SourceTypeWeb, expectedLayout: "grid"},
+		{layout: "native-grid", customBaseUrl: "#lk_egress=" + url.PathEscape(`{"speaking_timeline":true}`), fileType: livekit.EncodedFileType_MP4, expectedSourceType: types.SourceTypeSDK, expectedLayout: types.LayoutGrid},
+	} {
+		p, err := GetValidatedPipelineConfig(conf, &rpc.StartEgressRequest{
+			EgressId: "egress_ID",
+			Request: &rpc.StartEgressRequest_RoomComposite{
+				RoomComposite: &livekit.RoomCompositeEgressRequest{
+					RoomName:      "room",
+					Layout:        test.layout,
+					AudioOnly:     test.audioOnly,
+					CustomBaseUrl: test.customBaseUrl,
+					FileOutputs: []*livekit.EncodedFileOutput{{
+						FileType: test.fileType,
+					}},
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, test.expectedSourceType, p.SourceType)
+		require.Equal(t, test.expectedSourceType == types.SourceTypeWeb, p.AwaitStartSignal)
+		if !test.audioOnly {
+			require.Equal(t, test.expectedLayout, p.Layout)
+		}
+	}
+}
+
+func TestTrackTranscoding(t *testing.T) {
+	conf := &ServiceConfig{
+		BaseConfig: BaseConfig{
+			ApiKey:    "key",
+			ApiSecret: "secret",
+			WsUrl:     "wss://localhost:7880",
+		},
+	}
+	options := "#lk_egress=" + url.PathEscape(`{"track_transcoding":{"video_bitrate":1500}}`)
+
+	for _, test := range []struct {
+		filepath         string
+		kind             lksdk.TrackKind
+		mimeType         types.MimeType
+		expectedType     types.OutputType
+		expectedCodec    types.MimeType
+		expectedFilepath string
+		expectedErr      bool
+	}{
+		{filepath: "track", kind: lksdk.TrackKindAudio, mimeType: types.MimeTypeOpus, expectedType: types.OutputTypeOGG, expectedFilepath: "track.ogg"},
+		{filepath: "track.m4a", kind: lksdk.TrackKindAudio, mimeType: types.MimeTypeOpus, expectedType: types.OutputTypeMP4, expectedCodec: types.MimeTypeAAC, expectedFilepath: "track.m4a"},
+		{filepath: "track.wav", kind: lksdk.TrackKindAudio, mimeType: types.MimeTypeOpus, expectedType: types.OutputTypeWAV, expectedCodec: types.MimeTypeRawAudio, expectedFilepath: "track.wav"},
+		{filepath: "track.mp4", kind: lksdk.TrackKindVideo, mimeType: types.MimeTypeVP8, expectedType: types.OutputTypeMP4, expectedCodec: types.MimeTypeH264, expectedFilepath: "track.mp4"},
+		{filepath: "track.webm", kind: lksdk.TrackKindVideo, mimeType: types.MimeTypeVP8, expectedType: types.OutputTypeWebM, expectedFilepath: "track.webm"},
+		{filepath: "track.mp4", kind: lksdk.TrackKindVideo, mimeType: types.MimeTypeH264, expectedType: types.OutputTypeMP4, expectedFilepath: "track.mp4"},
+		{filepath: "track.webm", kind: lksdk.TrackKindVideo, mimeType: types.MimeTypeH264, expectedType: types.OutputTypeWebM, expectedCodec: types.MimeTypeVP8, expectedFilepath: "track.webm"},
+		{filepath: "track.ogg", kind: lksdk.TrackKindVideo, mimeType: types.MimeTypeH264, expectedErr: true},
+	} {
+		p, err := GetValidatedPipelineConfig(conf, &rpc.StartEgressRequest{
+			EgressId: "egress_ID",
+			Request: &rpc.StartEgressRequest_Track{
+				Track: &livekit.TrackEgressRequest{
+					RoomName: "room",
+					TrackId:  "track_ID",
+					Output: &livekit.TrackEgressRequest_File{
+						File: &livekit.DirectFileOutput{Filepath: test.filepath + options},
+					},
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		err = p.UpdateTrackOutput(&TrackSource{Kind: test.kind, MimeType: test.mimeType})
+		if test.expectedErr {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.NoError(t, p.UpdateInfoFromSDK("track_ID", nil, 0, 0))
+
+		o := p.GetFileConfig()
+		require.Equal(t, test.expectedType, o.OutputType)
+		require.Equal(t, test.expectedFilepath, o.StorageFilepath)
+		if test.kind == lksdk.TrackKindAudio {
+			require.Equal(t, test.expectedCodec, p.AudioOutCodec)
+		} else {
+			require.Equal(t, test.expectedCodec, p.VideoOutCodec)
+			if test.expectedCodec != "" {
+				require.Equal(t, int32(1500), p.VideoBitrate)
+			}
+		}
+	}
+}
+
+func TestTrackOutputErrors(t *testing.T) {
+	conf := &ServiceConfig{
+		BaseConfig: BaseConfig{
+			ApiKey:    "key",
+			ApiSecret: "secret",
+			WsUrl:     "wss://localhost:7880",
+		},
+	}
+
+	_, err := GetValidatedPipelineConfig(conf, &rpc.StartEgressRequest{
+		EgressId: "egress_ID",
+		Request: &rpc.StartEgressRequest_Track{
+			Track: &livekit.TrackEgressRequest{
+				RoomName: "room",
+				TrackId:  "track_ID",
+			},
+		},
+	})
+	require.Error(t, err)
+}
+
+func TestDefaultVideoCodec(t *testing.T) {
+	conf := &ServiceConfig{
+		BaseConfig: BaseConfig{
+			ApiKey:            "key",
+			ApiSecret:         "secret",
+			WsUrl:             "wss://localhost:7880",
+			DefaultVideoCodec: "h265",
+		},
+	}
+
+	for _, test := range []struct {
+		streamUrls    []string
+		expectedCodec types.MimeType
+	}{
+		{expectedCodec: types.MimeTypeH265},
+		{streamUrls: []string{"rtmp://localhost/live/stream"}, expectedCodec: types.MimeTypeH264},
+		{streamUrls: []string{"srt://localhost:8890"}, expectedCodec: types.MimeTypeH265},
+	} {
+		req := &livekit.TrackCompositeEgressRequest{
+			RoomName:     "room",
+			AudioTrackId: "audio_track",
+			VideoTrackId: "video_track",
+			FileOutputs: []*livekit.EncodedFileOutput{{
+				FileType: livekit.EncodedFileType_MP4,
+			}},
+		}
+		if len(test.streamUrls) > 0 {
+			req.StreamOutputs = []*livekit.StreamOutput{{Urls: test.streamUrls}}
+		}
+
+		p, err := GetValidatedPipelineConfig(conf, &rpc.StartEgressRequest{
+			EgressId: "egress_ID",
+			Request: &rpc.StartEgressRequest_TrackComposite{
+				TrackComposite: req,
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, test.expectedCodec, p.VideoOutCodec)
+	}
+}
+
+func TestSpeakingTimeline(t *testing.T) {
+	timeline := NewSpeakingTimeline()
+	timeline.AddTrack("a_level", "alice")
+	timeline.AddTrack("b_level", "bob")
+
+	ms := func(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+	for i := 0; i <= 3000; i += 100 {
+		aliceLevel, bobLevel := -80.0, -80.0
+		if i >= 500 && i < 1500 {
+			aliceLevel = -20
+		}
+		if i == 1700 {
+			// too short
+			bobLevel = -20
+		}
+		if i >= 2500 {
+			bobLevel = -30
+		}
+		timeline.UpdateLevel("a_level", aliceLevel, ms(i))
+		timeline.UpdateLevel("b_level", bobLevel, ms(i))
+		timeline.UpdateLevel("unknown", -10, ms(i))
+	}
+
+	require.Equal(t, []SpeakingInterval{
+		{Identity: "alice", Start: ms(500), End: ms(1400)},
+		{Identity: "bob", Start: ms(2500), End: ms(3000)},
+	}, timeline.Close())
+}
@@ -0,0 +1,212 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+)
+
+const defaultTranscriptionTopic = "lk.transcription"
+
+type DataRecordType string
+
+const (
+	DataRecordMessage       DataRecordType = "data"
+	DataRecordTranscription DataRecordType = "transcription"
+)
+
+type DataRecord struct {
+	Type     DataRecordType
+	Start    time.Duration // offset from the start of the recording
+	End      time.Duration // transcriptions only
+	Identity string
+
+	// data messages
+	Topic   string
+	Payload []byte
+
+	// transcriptions
+	TrackID  string
+	Language string
+	Text     string
+}
+
+// DataRecorder collects data messages and final transcription segments received during the recording,
+// along with cues loaded from a caption source
+type DataRecorder struct {
+	conf               *DataCaptureConfig
+	transcriptionTopic string
+
+	mu             sync.Mutex
+	records        []*DataRecord
+	transcriptions []*DataRecord // ordered by start time
+	segmentStarts  map[string]time.Duration
+}
+
+func NewDataRecorder(conf *DataCaptureConfig) *DataRecorder {
+	transcriptionTopic := conf.TranscriptionTopic
+	if transcriptionTopic == "" {
+		transcriptionTopic = defaultTranscriptionTopic
+	}
+
+	return &DataRecorder{
+		conf:               conf,
+		transcriptionTopic: transcriptionTopic,
+		segmentStarts:      make(map[string]time.Duration),
+	}
+}
+
+// OnMessage records a data message. startedAt and receivedAt are unix nanoseconds
+func (r *DataRecorder) OnMessage(identity, topic string, payload []byte, startedAt, receivedAt int64) {
+	if topic == r.transcriptionTopic && r.conf.Transcriptions {
+		transcription := &livekit.Transcription{}
+		if err := protojson.Unmarshal(payload, transcription); err != nil {
+			logger.Debugw("failed to parse transcription", err, "identity", identity)
+			return
+		}
+		r.OnTranscription(transcription, startedAt, receivedAt)
+		return
+	}
+
+	if !r.conf.Messages || (len(r.conf.Topics) > 0 && !slices.Contains(r.conf.Topics, topic)) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records = append(r.records, &DataRecord{
+		Type:     DataRecordMessage,
+		Start:    offset(startedAt, receivedAt),
+		Identity: identity,
+		Topic:    topic,
+		Payload:  payload,
+	})
+}
+
+// OnTranscription records final segments. Segment start and end times are unix milliseconds - when they are
+// not set, a segment starts when an interim version was first received, and ends when it was finalized.
+func (r *DataRecorder) OnTranscription(transcription *livekit.Transcription, startedAt, receivedAt int64) {
+	if !r.conf.Transcriptions {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, segment := range transcription.Segments {
+		start, ok := r.segmentStarts[segment.Id]
+		if !ok {
+			start = offset(startedAt, receivedAt)
+			r.segmentStarts[segment.Id] = start
+		}
+		if !segment.Final {
+			continue
+		}
+		delete(r.segmentStarts, segment.Id)
+
+		end := offset(startedAt, receivedAt)
+		if segment.StartTime > 0 && segment.EndTime >= segment.StartTime {
+			start = offset(startedAt, int64(segment.StartTime)*int64(time.Millisecond))
+			end = offset(startedAt, int64(segment.EndTime)*int64(time.Millisecond))
+		}
+
+		record := &DataRecord{
+			Type:     DataRecordTranscription,
+			Start:    start,
+			End:      end,
+			Identity: transcription.TranscribedParticipantIdentity,
+			TrackID:  transcription.TrackId,
+			Language: segment.Language,
+			Text:     segment.Text,
+		}
+		r.records = append(r.records, record)
+		r.insertTranscription(record)
+	}
+}
+
+// AddCaptions adds cues from the caption source. They are used for captions, but not written to the data file
+func (r *DataRecorder) AddCaptions(cues []DataRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range cues {
+		cue := cues[i]
+		cue.Type = DataRecordTranscription
+		r.insertTranscription(&cue)
+	}
+}
+
+func (r *DataRecorder) insertTranscription(record *DataRecord) {
+	i, _ := slices.BinarySearchFunc(r.transcriptions, record.Start, func(t *DataRecord, start time.Duration) int {
+		// insert after equal start times, keeping the order received
+		if t.Start <= start {
+			return -1
+		}
+		return 1
+	})
+	r.transcriptions = slices.Insert(r.transcriptions, i, record)
+}
+
+func (r *DataRecorder) TranscriptionsEnabled() bool {
+	return r.conf.Transcriptions || r.conf.CaptionSource != ""
+}
+
+// Records returns data messages and transcriptions in the order received
+func (r *DataRecorder) Records() []DataRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records := make([]DataRecord, 0, len(r.records))
+	for _, record := range r.records {
+		records = append(records, *record)
+	}
+	return records
+}
+
+// Transcriptions returns transcriptions and caption source cues ordered by start time
+func (r *DataRecorder) Transcriptions() []DataRecord {
+	return r.TranscriptionsBetween(0, math.MaxInt64)
+}
+
+// TranscriptionsBetween returns transcriptions overlapping [start, end), ordered by start time
+func (r *DataRecorder) TranscriptionsBetween(start, end time.Duration) []DataRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var transcriptions []DataRecord
+	for _, t := range r.transcriptions {
+		if t.Start >= end {
+			break
+		}
+		if t.End > start {
+			transcriptions = append(transcriptions, *t)
+		}
+	}
+	return transcriptions
+}
+
+// offset returns the time since startedAt, or 0 for anything before the recording started
+func offset(startedAt, ts int64) time.Duration {
+	return time.Duration(max(ts-startedAt, 0))
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestDataRecorder(t *testing.T) {
+	recorder := NewDataRecorder(&DataCaptureConfig{
+		Messages:       true,
+		Topics:         []string{"chat"},
+		Transcriptions: true,
+	})
+
+	startedAt := time.Unix(1000, 0).UnixNano()
+	at := func(d time.Duration) int64 {
+		return startedAt + int64(d)
+	}
+
+	// json on the transcription topic, without segment times
+	transcription := func(text string, final bool) []byte {
+		return []byte(fmt.Sprintf(
+			`{"transcribedParticipantIdentity":"alice","trackId":"TR_1","segments":[{"id":"SG_1","text":%q,"final":%t}]}`,
+			text, final,
+		))
+	}
+
+	recorder.OnMessage("bob", "chat", []byte("before"), startedAt, at(-time.Second))
+	recorder.OnMessage("bob", "chat", []byte("hello"), startedAt, at(time.Second))
+	recorder.OnMessage("bob", "cursor", []byte("ignored"), startedAt, at(time.Second*2))
+	recorder.OnMessage("agent", "lk.transcription", transcription("good", false), startedAt, at(time.Second*3))
+	recorder.OnMessage("agent", "lk.transcription", transcription("good morning", true), startedAt, at(time.Second*5))
+
+	// transcription packet, with segment times
+	recorder.OnTranscription(&livekit.Transcription{
+		TranscribedParticipantIdentity: "bob",
+		TrackId:                        "TR_2",
+		Segments: []*livekit.TranscriptionSegment{{
+			Id:        "SG_2",
+			Text:      "hi",
+			StartTime: uint64(time.Unix(1002, 0).UnixMilli()),
+			EndTime:   uint64(time.Unix(1002, int64(time.Millisecond*500)).UnixMilli()),
+			Final:     true,
+			Language:  "en",
+		}},
+	}, startedAt, at(time.Second*6))
+
+	require.Equal(t, []DataRecord{
+		{Type: DataRecordMessage, Start: 0, Identity: "bob", Topic: "chat", Payload: []byte("before")},
+		{Type: DataRecordMessage, Start: time.Second, Identity: "bob", Topic: "chat", Payload: []byte("hello")},
+		{Type: DataRecordTranscription, Start: time.Second * 3, End: time.Second * 5, Identity: "alice", TrackID: "TR_1", Text: "good morning"},
+		{Type: DataRecordTranscription, Start: time.Second * 2, End: time.Millisecond * 2500, Identity: "bob", TrackID: "TR_2", Language: "en", Text: "hi"},
+	}, recorder.Records())
+
+	// caption source cues are captions, but not data records
+	recorder.AddCaptions([]DataRecord{{Start: time.Second * 4, End: time.Second * 6, Text: "caption"}})
+	require.Len(t, recorder.Records(), 4)
+
+	var texts []string
+	for _, transcription := range recorder.Transcriptions() {
+		texts = append(texts, transcription.Text)
+	}
+	require.Equal(t, []string{"hi", "good morning", "caption"}, texts)
+
+	texts = nil
+	for _, transcription := range recorder.TranscriptionsBetween(time.Millisecond*2500, time.Second*4) {
+		texts = append(texts, transcription.Text)
+	}
+	require.Equal(t, []string{"good morning"}, texts)
+}
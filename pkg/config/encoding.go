@@ -20,6 +20,25 @@ import (
 	"github.com/livekit/protocol/livekit"
 )
 
+// AV1Encoders are the supported av1 encoder elements, in order of preference
+var AV1Encoders = []string{"svtav1enc", "rav1enc", "av1enc"}
+
+var videoCodecNames = map[string]types.MimeType{
+	"h264": types.MimeTypeH264,
+	"h265": types.MimeTypeH265,
+	"vp8":  types.MimeTypeVP8,
+	"vp9":  types.MimeTypeVP9,
+	"av1":  types.MimeTypeAV1,
+}
+
+// getDefaultVideoCodec returns the configured default video codec if compatible with the output type
+func (p *PipelineConfig) getDefaultVideoCodec(outputType types.OutputType) types.MimeType {
+	if codec, ok := videoCodecNames[p.DefaultVideoCodec]; ok && types.CodecCompatibility[outputType][codec] {
+		return codec
+	}
+	return types.DefaultVideoCodecs[outputType]
+}
+
 func (p *PipelineConfig) applyPreset(preset livekit.EncodingOptionsPreset) {
 	switch preset {
 	case livekit.EncodingOptionsPreset_H264_720P_30:
@@ -100,6 +119,9 @@ func (p *PipelineConfig) applyAdvanced(advanced *livekit.EncodingOptions) error
 	case livekit.VideoCodec_H264_HIGH:
 		p.VideoOutCodec = types.MimeTypeH264
 		p.VideoProfile = types.ProfileHigh
+
+	case livekit.VideoCodec_VP8:
+		p.VideoOutCodec = types.MimeTypeVP8
 	}
 
 	if advanced.Width > 0 {
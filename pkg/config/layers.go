@@ -0,0 +1,167 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+)
+
+type VideoLayerSwitch struct {
+	TrackID   string
+	Quality   string // published layer matching the received resolution, if known
+	Requested string // layer held by the simulcast_layer option, if any
+	Width     int
+	Height    int
+	Offset    time.Duration // pts of the first frame at this size, from the start of the recording
+}
+
+// VideoLayerLog records changes in the received resolution of each video track,
+// which is how simulcast layer switches by the sfu show up on the subscriber side
+type VideoLayerLog struct {
+	mu       sync.Mutex
+	tracks   map[string]*videoLayerTrack
+	current  map[string]*VideoLayerSwitch
+	switches []*VideoLayerSwitch
+}
+
+type videoLayerTrack struct {
+	layers []*livekit.VideoLayer
+	held   *livekit.VideoLayer
+	hold   func()
+}
+
+func NewVideoLayerLog() *VideoLayerLog {
+	return &VideoLayerLog{
+		tracks:  make(map[string]*videoLayerTrack),
+		current: make(map[string]*VideoLayerSwitch),
+	}
+}
+
+// AddTrack records the published layers of a track. If a layer is held, hold is called
+// to request it again whenever the sfu switches the track to a smaller layer
+func (l *VideoLayerLog) AddTrack(trackID string, layers []*livekit.VideoLayer, held *livekit.VideoLayer, hold func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tracks[trackID] = &videoLayerTrack{
+		layers: layers,
+		held:   held,
+		hold:   hold,
+	}
+}
+
+// OnResolution is called with the decoded size and pts of a track whenever its caps change
+func (l *VideoLayerLog) OnResolution(trackID string, width, height int, pts time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if c := l.current[trackID]; c != nil && c.Width == width && c.Height == height {
+		return
+	}
+
+	s := &VideoLayerSwitch{
+		TrackID: trackID,
+		Width:   width,
+		Height:  height,
+		Offset:  pts,
+	}
+	track := l.tracks[trackID]
+	if track != nil {
+		s.Quality = matchVideoLayer(track.layers, width, height)
+		if track.held != nil {
+			s.Requested = strings.ToLower(track.held.Quality.String())
+		}
+	}
+	logger.Infow("video layer changed", "trackID", trackID, "quality", s.Quality, "width", width, "height", height)
+	l.current[trackID] = s
+	l.switches = append(l.switches, s)
+
+	if track != nil && track.held != nil && width*height < int(track.held.Width*track.held.Height) {
+		logger.Infow("requesting held video layer", "trackID", trackID, "quality", s.Requested)
+		go track.hold()
+	}
+}
+
+func (l *VideoLayerLog) Switches() []*VideoLayerSwitch {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]*VideoLayerSwitch{}, l.switches...)
+}
+
+// matchVideoLayer returns the published layer closest in pixel count, which ignores orientation
+func matchVideoLayer(layers []*livekit.VideoLayer, width, height int) string {
+	var match *livekit.VideoLayer
+	var diff int
+	for _, layer := range layers {
+		d := abs(int(layer.Width)*int(layer.Height) - width*height)
+		if match == nil || d < diff {
+			match = layer
+			diff = d
+		}
+	}
+	if match == nil {
+		return ""
+	}
+	return strings.ToLower(match.Quality.String())
+}
+
+// GetHeldVideoLayer returns the published layer requested by the simulcast_layer option, or nil if none is held.
+// A quality holds the largest layer up to that quality, and match holds the smallest layer covering the output
+func GetHeldVideoLayer(layers []*livekit.VideoLayer, simulcastLayer string, width, height int32) *livekit.VideoLayer {
+	var held *livekit.VideoLayer
+	switch simulcastLayer {
+	case "high", "medium", "low":
+		quality := livekit.VideoQuality(livekit.VideoQuality_value[strings.ToUpper(simulcastLayer)])
+		for _, layer := range layers {
+			if layer.Quality <= quality && (held == nil || layer.Quality > held.Quality) {
+				held = layer
+			}
+		}
+
+	case "match":
+		// orientation is ignored
+		long, short := uint32(max(width, height)), uint32(min(width, height))
+		for _, layer := range layers {
+			switch {
+			case held == nil:
+				held = layer
+			case coversSize(layer, long, short):
+				if !coversSize(held, long, short) || layer.Width*layer.Height < held.Width*held.Height {
+					held = layer
+				}
+			case !coversSize(held, long, short) && layer.Width*layer.Height > held.Width*held.Height:
+				held = layer
+			}
+		}
+	}
+	return held
+}
+
+func coversSize(layer *livekit.VideoLayer, long, short uint32) bool {
+	return max(layer.Width, layer.Height) >= long && min(layer.Width, layer.Height) >= short
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
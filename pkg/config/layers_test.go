@@ -0,0 +1,86 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var testVideoLayers = []*livekit.VideoLayer{
+	{Quality: livekit.VideoQuality_LOW, Width: 320, Height: 180},
+	{Quality: livekit.VideoQuality_MEDIUM, Width: 640, Height: 360},
+	{Quality: livekit.VideoQuality_HIGH, Width: 1280, Height: 720},
+}
+
+func TestVideoLayerLog(t *testing.T) {
+	held := make(chan struct{}, 1)
+	l := NewVideoLayerLog()
+	l.AddTrack("TR_1", testVideoLayers, testVideoLayers[2], func() {
+		held <- struct{}{}
+	})
+
+	l.OnResolution("TR_1", 1280, 720, 0)
+	l.OnResolution("TR_1", 1280, 720, time.Second)
+	l.OnResolution("TR_1", 640, 360, 5*time.Second)
+	l.OnResolution("TR_2", 480, 640, 7*time.Second)
+
+	switches := l.Switches()
+	require.Len(t, switches, 3)
+	require.Equal(t, "high", switches[0].Quality)
+	require.Equal(t, "high", switches[0].Requested)
+	require.Equal(t, "medium", switches[1].Quality)
+	require.Equal(t, 640, switches[1].Width)
+	require.Equal(t, 5*time.Second, switches[1].Offset)
+	require.Equal(t, "", switches[2].Quality)
+	require.Equal(t, "", switches[2].Requested)
+
+	// the held layer is requested again after the downgrade
+	select {
+	case <-held:
+	case <-time.After(time.Second):
+		t.Fatal("held layer not requested")
+	}
+}
+
+func TestGetHeldVideoLayer(t *testing.T) {
+	for _, test := range []struct {
+		name           string
+		simulcastLayer string
+		width, height  int32
+		layers         []*livekit.VideoLayer
+		expected       *livekit.VideoLayer
+	}{
+		{name: "none", simulcastLayer: "", expected: nil},
+		{name: "medium", simulcastLayer: "medium", expected: testVideoLayers[1]},
+		{name: "missing high", simulcastLayer: "high", layers: testVideoLayers[:2], expected: testVideoLayers[1]},
+		{name: "match", simulcastLayer: "match", width: 640, height: 360, expected: testVideoLayers[1]},
+		{name: "match between", simulcastLayer: "match", width: 854, height: 480, expected: testVideoLayers[2]},
+		{name: "match portrait", simulcastLayer: "match", width: 180, height: 320, expected: testVideoLayers[0]},
+		{name: "match larger", simulcastLayer: "match", width: 1920, height: 1080, expected: testVideoLayers[2]},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			layers := test.layers
+			if layers == nil {
+				layers = testVideoLayers
+			}
+			require.Equal(t, test.expected, GetHeldVideoLayer(layers, test.simulcastLayer, test.width, test.height))
+		})
+	}
+}
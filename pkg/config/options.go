@@ -0,0 +1,470 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/types"
+	"github.com/livekit/protocol/egress"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+)
+
+const optionsParam = "lk_egress"
+
+// RequestOptions are egress settings the request has no fields for. They are passed as url encoded json
+// in an lk_egress fragment parameter, e.g. recording.mp4#lk_egress={"track_transcoding":{"width":640}},
+// on the web url, custom base url, or any file, segment or image output location.
+// Options are removed before the request is used or reported, and each option can only be set once.
+type RequestOptions struct {
+	VideoCodec       string                 `yaml:"video_codec"`       // h264, h265, vp8, vp9 or av1, overrides the encoding options
+	AV1Encoder       string                 `yaml:"av1_encoder"`       // svtav1enc, rav1enc or av1enc (default the first one installed)
+	TrackTranscoding TrackTranscodingConfig `yaml:"track_transcoding"` // encoding params for transcoded track egress
+	AudioProcessing  AudioProcessingConfig  `yaml:"audio_processing"`  // audio filters and loudness normalization
+	AudioFile        AudioFileConfig        `yaml:"audio_file"`        // sample rate and channels for wav, flac and mp3 outputs
+	DataCapture      DataCaptureConfig      `yaml:"data_capture"`      // record data messages and transcriptions, for sdk egress
+	SpeakingTimeline bool                   `yaml:"speaking_timeline"` // record when each participant spoke, for sdk room composite and participant egress
+	Overlays         OverlayConfig          `yaml:"overlays"`          // logos, text and clock drawn on video
+	VideoScaling     VideoScalingConfig     `yaml:"video_scaling"`     // how participant and track composite video is fit to the output size
+	SimulcastLayer   string                 `yaml:"simulcast_layer"`   // high, medium, low or match, held for sdk egress video (default high, adapted by the sfu)
+	IntroOutro       IntroOutroConfig       `yaml:"intro_outro"`       // clips added before and after file and segment recordings
+	Web              WebConfig              `yaml:"web"`               // headers, cookies and storage for chrome, never logged
+
+	imageSprites      map[*livekit.ImageOutput]*ImageSpritesConfig
+	imageSceneCapture map[*livekit.ImageOutput]*ImageSceneCaptureConfig
+	imagePreviews     map[types.EgressType]*ImagePreviewConfig
+}
+
+// recordingOutputOptions are the options accepted on a file or segment output location. The preview is made from that output
+type recordingOutputOptions struct {
+	RequestOptions `yaml:",inline"`
+	ImagePreview   *ImagePreviewConfig `yaml:"image_preview"`
+}
+
+type ImagePreviewConfig struct {
+	Format         string        `yaml:"format"`          // webp or gif (default webp)
+	FilenamePrefix string        `yaml:"filename_prefix"` // default {room_name}-{time}-preview
+	Width          int32         `yaml:"width"`           // default 320, height keeps the aspect ratio
+	Duration       time.Duration `yaml:"duration"`        // length of the preview (default 5s)
+	Window         time.Duration `yaml:"window"`          // frames are sampled from the start of the recording (default 1m)
+	Framerate      int32         `yaml:"framerate"`       // default 10
+}
+
+// imageOutputOptions are the options accepted on an image output location. Sprites and scene capture only apply to that output
+type imageOutputOptions struct {
+	RequestOptions `yaml:",inline"`
+	Sprites        *ImageSpritesConfig      `yaml:"sprites"`
+	SceneCapture   *ImageSceneCaptureConfig `yaml:"scene_capture"`
+}
+
+type ImageSpritesConfig struct {
+	Columns    int32 `yaml:"columns"`     // default 10
+	Rows       int32 `yaml:"rows"`        // default 10
+	TileWidth  int32 `yaml:"tile_width"`  // default 160
+	TileHeight int32 `yaml:"tile_height"` // default 90
+}
+
+type ImageSceneCaptureConfig struct {
+	Threshold   float64       `yaml:"threshold"`    // fraction of the frame that must change (default 0.02)
+	MinInterval time.Duration `yaml:"min_interval"` // frames are compared at this interval (default 1s)
+	MaxInterval time.Duration `yaml:"max_interval"` // capture at least this often, even without changes (default 0, disabled)
+}
+
+type TrackTranscodingConfig struct {
+	Width        int32 `yaml:"width"`         // defaults to the track's resolution
+	Height       int32 `yaml:"height"`        // defaults to the track's resolution
+	VideoBitrate int32 `yaml:"video_bitrate"` // kbps
+	AudioBitrate int32 `yaml:"audio_bitrate"` // kbps
+}
+
+type AudioProcessingConfig struct {
+	PerTrack          bool    `yaml:"per_track"`           // process each track before mixing, instead of the mix
+	HighPassFrequency float64 `yaml:"high_pass_frequency"` // cutoff in Hz, 0 to disable
+	Compressor        bool    `yaml:"compressor"`          // soft-knee compressor followed by a limiter
+	TargetLoudness    float64 `yaml:"target_loudness"`     // EBU R128 target in LUFS (e.g. -23), 0 to disable
+}
+
+func (c *AudioProcessingConfig) Enabled() bool {
+	return c.HighPassFrequency > 0 || c.Compressor || c.TargetLoudness != 0
+}
+
+type AudioFileConfig struct {
+	SampleRate int32 `yaml:"sample_rate"` // Hz, defaults to the encoding options
+	Channels   int32 `yaml:"channels"`    // 1 or 2 for mp3, up to 8 for wav and flac (default 2)
+}
+
+type VideoScalingConfig struct {
+	Mode       string `yaml:"mode"`       // fit, fill or stretch (default fit)
+	Background string `yaml:"background"` // fit border color: black, white, red, green, blue or yellow (default black)
+}
+
+type IntroOutroConfig struct {
+	Intro string `yaml:"intro"` // http(s) url, or filepath relative to clips_dir, supports {room_name} and {egress_id}
+	Outro string `yaml:"outro"` // http(s) url, or filepath relative to clips_dir, supports {room_name} and {egress_id}
+}
+
+type DataCaptureConfig struct {
+	Messages           bool     `yaml:"messages"`            // record data messages
+	Topics             []string `yaml:"topics"`              // only record messages with these topics (default all)
+	Transcriptions     bool     `yaml:"transcriptions"`      // record transcriptions and create captions
+	TranscriptionTopic string   `yaml:"transcription_topic"` // topic carrying json encoded transcriptions (default lk.transcription)
+	Language           string   `yaml:"language"`            // caption language for hls subtitles, e.g. en
+
+	CaptionSource        string        `yaml:"caption_source"`         // http(s) url of a webvtt or srt file, timed from the start of the recording
+	CaptionSourceRefresh time.Duration `yaml:"caption_source_refresh"` // reload interval for a caption file which is still being written
+}
+
+func (c *DataCaptureConfig) Enabled() bool {
+	return c.Messages || c.Transcriptions || c.CaptionSource != ""
+}
+
+type OverlayConfig struct {
+	Images  []*ImageOverlay    `yaml:"images"`
+	Text    []*TextOverlay     `yaml:"text"`
+	Clock   *TextOverlay       `yaml:"clock"`   // text is a strftime format (default %H:%M:%S)
+	Outputs []types.EgressType `yaml:"outputs"` // file, stream, segments and images (default file, stream and segments)
+}
+
+type ImageOverlay struct {
+	Url     string  `yaml:"url"`     // http(s) url of a png or jpeg
+	X       int32   `yaml:"x"`       // offset in pixels, negative values are relative to the right edge
+	Y       int32   `yaml:"y"`       // offset in pixels, negative values are relative to the bottom edge
+	Width   int32   `yaml:"width"`   // defaults to the image width
+	Height  int32   `yaml:"height"`  // defaults to the image height
+	Opacity float64 `yaml:"opacity"` // 0 to 1 (default 1)
+
+	Filepath string `yaml:"-"` // set once downloaded
+}
+
+func (c *OverlayConfig) Enabled() bool {
+	return len(c.Images) > 0 || len(c.Text) > 0 || c.Clock != nil
+}
+
+// EnabledFor returns true if overlays are drawn on the output
+func (c *OverlayConfig) EnabledFor(egressType types.EgressType) bool {
+	if !c.Enabled() {
+		return false
+	}
+	if len(c.Outputs) == 0 {
+		return egressType != types.EgressTypeImages
+	}
+	return slices.Contains(c.Outputs, egressType)
+}
+
+func (c *OverlayConfig) validate() error {
+	for _, image := range c.Images {
+		if u, err := url.Parse(image.Url); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return errors.ErrInvalidInput(optionsParam + ".overlays.images.url")
+		}
+		if image.Opacity < 0 || image.Opacity > 1 {
+			return errors.ErrInvalidInput(optionsParam + ".overlays.images.opacity")
+		}
+	}
+
+	text := append([]*TextOverlay{}, c.Text...)
+	if c.Clock != nil {
+		text = append(text, c.Clock)
+	}
+	for _, t := range text {
+		switch t.HAlign {
+		case "", "left", "center", "right":
+		default:
+			return errors.ErrInvalidInput(optionsParam + ".overlays.halign")
+		}
+		switch t.VAlign {
+		case "", "top", "center", "bottom":
+		default:
+			return errors.ErrInvalidInput(optionsParam + ".overlays.valign")
+		}
+	}
+
+	for _, output := range c.Outputs {
+		switch output {
+		case types.EgressTypeFile, types.EgressTypeStream, types.EgressTypeSegments, types.EgressTypeImages:
+		default:
+			return errors.ErrInvalidInput(optionsParam + ".overlays.outputs")
+		}
+	}
+
+	return nil
+}
+
+// updateRequestOptions removes every lk_egress parameter from the request, and applies them
+func (p *PipelineConfig) updateRequestOptions(request *rpc.StartEgressRequest) error {
+	set := make(map[string]bool)
+	locations, outputs := getOptionLocations(request)
+	for _, location := range locations {
+		value, err := takeOptions(location)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			continue
+		}
+
+		if err = decodeOptions(value, set, &p.RequestOptions); err != nil {
+			return err
+		}
+	}
+
+	for _, output := range outputs {
+		value, err := takeOptions(output.location)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			continue
+		}
+
+		o := &recordingOutputOptions{RequestOptions: p.RequestOptions}
+		if err = decodeOptions(value, set, o); err != nil {
+			return err
+		}
+		p.RequestOptions = o.RequestOptions
+
+		// previews can be set on each recording output
+		delete(set, "image_preview")
+		if o.ImagePreview != nil {
+			if p.imagePreviews == nil {
+				p.imagePreviews = make(map[types.EgressType]*ImagePreviewConfig)
+			}
+			p.imagePreviews[output.egressType] = o.ImagePreview
+		}
+	}
+
+	for _, images := range getImageOutputs(request) {
+		value, err := takeOptions(&images.FilenamePrefix)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			continue
+		}
+
+		o := &imageOutputOptions{RequestOptions: p.RequestOptions}
+		if err = decodeOptions(value, set, o); err != nil {
+			return err
+		}
+		p.RequestOptions = o.RequestOptions
+
+		// sprites and scene capture can be set on each image output
+		delete(set, "sprites")
+		delete(set, "scene_capture")
+		if o.Sprites != nil {
+			s := o.Sprites
+			if s.Columns < 0 || s.Rows < 0 || s.TileWidth < 0 || s.TileHeight < 0 {
+				return errors.ErrInvalidInput(optionsParam + ".sprites")
+			}
+			if p.imageSprites == nil {
+				p.imageSprites = make(map[*livekit.ImageOutput]*ImageSpritesConfig)
+			}
+			p.imageSprites[images] = s
+		}
+		if o.SceneCapture != nil {
+			if p.imageSceneCapture == nil {
+				p.imageSceneCapture = make(map[*livekit.ImageOutput]*ImageSceneCaptureConfig)
+			}
+			p.imageSceneCapture[images] = o.SceneCapture
+		}
+	}
+
+	if err := p.RequestOptions.validate(); err != nil {
+		return err
+	}
+
+	// checked again once templates are replaced
+	_, _, err := p.getClipURIs(nil)
+	return err
+}
+
+func decodeOptions(value string, set map[string]bool, out interface{}) error {
+	var keys map[string]interface{}
+	if err := yaml.Unmarshal([]byte(value), &keys); err != nil {
+		return errors.ErrInvalidInput(optionsParam)
+	}
+	for key := range keys {
+		if set[key] {
+			return errors.ErrInvalidInput(optionsParam + "." + key)
+		}
+		set[key] = true
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(value)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(out); err != nil {
+		return errors.ErrInvalidInput(optionsParam)
+	}
+	return nil
+}
+
+func (o *RequestOptions) validate() error {
+	if _, ok := videoCodecNames[o.VideoCodec]; o.VideoCodec != "" && !ok {
+		return errors.ErrInvalidInput(optionsParam + ".video_codec")
+	}
+	if o.AV1Encoder != "" && !slices.Contains(AV1Encoders, o.AV1Encoder) {
+		return errors.ErrInvalidInput(optionsParam + ".av1_encoder")
+	}
+	t := o.TrackTranscoding
+	if t.Width < 0 || t.Height < 0 || t.VideoBitrate < 0 || t.AudioBitrate < 0 {
+		return errors.ErrInvalidInput(optionsParam + ".track_transcoding")
+	}
+	if a := o.AudioProcessing; a.HighPassFrequency < 0 {
+		return errors.ErrInvalidInput(optionsParam + ".audio_processing.high_pass_frequency")
+	} else if a.TargetLoudness != 0 && (a.TargetLoudness < -70 || a.TargetLoudness > -5) {
+		// supported by audioloudnorm
+		return errors.ErrInvalidInput(optionsParam + ".audio_processing.target_loudness")
+	}
+	if f := o.AudioFile; f.SampleRate < 0 {
+		return errors.ErrInvalidInput(optionsParam + ".audio_file.sample_rate")
+	} else if f.Channels < 0 {
+		return errors.ErrInvalidInput(optionsParam + ".audio_file.channels")
+	}
+	if d := o.DataCapture; d.CaptionSource != "" {
+		if u, err := url.Parse(d.CaptionSource); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return errors.ErrInvalidInput(optionsParam + ".data_capture.caption_source")
+		}
+	}
+	if o.DataCapture.CaptionSourceRefresh < 0 {
+		return errors.ErrInvalidInput(optionsParam + ".data_capture.caption_source_refresh")
+	}
+	switch o.VideoScaling.Mode {
+	case "", "fit", "fill", "stretch":
+	default:
+		return errors.ErrInvalidInput(optionsParam + ".video_scaling.mode")
+	}
+	switch o.VideoScaling.Background {
+	case "", "black", "white", "red", "green", "blue", "yellow":
+	default:
+		return errors.ErrInvalidInput(optionsParam + ".video_scaling.background")
+	}
+	switch o.SimulcastLayer {
+	case "", "high", "medium", "low", "match":
+	default:
+		return errors.ErrInvalidInput(optionsParam + ".simulcast_layer")
+	}
+	if err := o.Web.validate(); err != nil {
+		return err
+	}
+	return o.Overlays.validate()
+}
+
+// outputLocation is a file or segment output location, which may carry output options
+type outputLocation struct {
+	egressType types.EgressType
+	location   *string
+}
+
+// getOptionLocations returns every request field which may carry options, and the recording output locations
+func getOptionLocations(request *rpc.StartEgressRequest) ([]*string, []outputLocation) {
+	var locations []*string
+	var outputs []outputLocation
+	switch req := request.Request.(type) {
+	case *rpc.StartEgressRequest_RoomComposite:
+		locations = append(locations, &req.RoomComposite.CustomBaseUrl)
+		outputs = getOutputLocations(req.RoomComposite)
+	case *rpc.StartEgressRequest_Web:
+		locations = append(locations, &req.Web.Url)
+		outputs = getOutputLocations(req.Web)
+	case *rpc.StartEgressRequest_Participant:
+		outputs = getOutputLocations(req.Participant)
+	case *rpc.StartEgressRequest_TrackComposite:
+		outputs = getOutputLocations(req.TrackComposite)
+	case *rpc.StartEgressRequest_Track:
+		// track egress has no previews
+		if file := req.Track.GetFile(); file != nil {
+			locations = append(locations, &file.Filepath)
+		}
+	}
+	return locations, outputs
+}
+
+func getOutputLocations(req egress.EncodedOutput) []outputLocation {
+	var outputs []outputLocation
+	for _, file := range req.GetFileOutputs() {
+		outputs = append(outputs, outputLocation{types.EgressTypeFile, &file.Filepath})
+	}
+	for _, segments := range req.GetSegmentOutputs() {
+		outputs = append(outputs, outputLocation{types.EgressTypeSegments, &segments.FilenamePrefix})
+	}
+	if r, ok := req.(egress.EncodedOutputDeprecated); ok {
+		if file := r.GetFile(); file != nil {
+			outputs = append(outputs, outputLocation{types.EgressTypeFile, &file.Filepath})
+		} else if segments := r.GetSegments(); segments != nil {
+			outputs = append(outputs, outputLocation{types.EgressTypeSegments, &segments.FilenamePrefix})
+		}
+	}
+	return outputs
+}
+
+// getImageOutputs returns every image output, which may carry output options
+func getImageOutputs(request *rpc.StartEgressRequest) []*livekit.ImageOutput {
+	switch req := request.Request.(type) {
+	case *rpc.StartEgressRequest_RoomComposite:
+		return req.RoomComposite.ImageOutputs
+	case *rpc.StartEgressRequest_Web:
+		return req.Web.ImageOutputs
+	case *rpc.StartEgressRequest_Participant:
+		return req.Participant.ImageOutputs
+	case *rpc.StartEgressRequest_TrackComposite:
+		return req.TrackComposite.ImageOutputs
+	}
+	return nil
+}
+
+// withoutOptions returns s with its options removed. Invalid options are left in place, and fail the request in Update
+func withoutOptions(s string) string {
+	_, _ = takeOptions(&s)
+	return s
+}
+
+// takeOptions removes the lk_egress parameter from the fragment of s, returning its decoded value
+func takeOptions(s *string) (string, error) {
+	base, fragment, found := strings.Cut(*s, "#")
+	if !found {
+		return "", nil
+	}
+
+	var value string
+	var params []string
+	for _, param := range strings.Split(fragment, "&") {
+		if v, ok := strings.CutPrefix(param, optionsParam+"="); ok {
+			decoded, err := url.PathUnescape(v)
+			if err != nil || value != "" {
+				return "", errors.ErrInvalidInput(optionsParam)
+			}
+			value = decoded
+		} else {
+			params = append(params, param)
+		}
+	}
+	if value == "" {
+		return "", nil
+	}
+
+	if len(params) > 0 {
+		*s = base + "#" + strings.Join(params, "&")
+	} else {
+		*s = base
+	}
+	return value, nil
+}
@@ -0,0 +1,509 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/egress/pkg/types"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+)
+
+func TestTakeOptions(t *testing.T) {
+	options := `{"track_transcoding":{"width":640}}`
+	encoded := url.PathEscape(options)
+
+	for _, test := range []struct {
+		location         string
+		expectedLocation string
+		expectedValue    string
+		expectedErr      bool
+	}{
+		{location: "recording.mp4", expectedLocation: "recording.mp4"},
+		{location: "recording.mp4#lk_egress=" + encoded, expectedLocation: "recording.mp4", expectedValue: options},
+		{location: "https://example.com/#/room&lk_egress=" + encoded, expectedLocation: "https://example.com/#/room", expectedValue: options},
+		{location: "https://example.com/#lk_egress=" + encoded + "&tab=2", expectedLocation: "https://example.com/#tab=2", expectedValue: options},
+		{location: "https://example.com/#tab=2", expectedLocation: "https://example.com/#tab=2"},
+		{location: "recording.mp4#lk_egress=%7", expectedErr: true},
+	} {
+		location := test.location
+		value, err := takeOptions(&location)
+		if test.expectedErr {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, test.expectedLocation, location)
+		require.Equal(t, test.expectedValue, value)
+	}
+}
+
+func TestRequestOptions(t *testing.T) {
+	conf := &ServiceConfig{
+		BaseConfig: BaseConfig{
+			ApiKey:    "key",
+			ApiSecret: "secret",
+			WsUrl:     "wss://localhost:7880",
+		},
+	}
+	withOptions := func(location, options string) string {
+		return location + "#lk_egress=" + url.PathEscape(options)
+	}
+
+	for _, test := range []struct {
+		name        string
+		url         string
+		filepath    string
+		expectedErr bool
+	}{
+		{
+			name:     "url",
+			url:      withOptions("https://example.com", `{"track_transcoding":{"width":640}}`),
+			filepath: "recording.mp4",
+		},
+		{
+			name:     "output",
+			url:      "https://example.com",
+			filepath: withOptions("recording.mp4", `{"track_transcoding":{"width":640}}`),
+		},
+		{
+			name:        "duplicate",
+			url:         withOptions("https://example.com", `{"track_transcoding":{"width":640}}`),
+			filepath:    withOptions("recording.mp4", `{"track_transcoding":{"height":360}}`),
+			expectedErr: true,
+		},
+		{
+			name:        "unknown",
+			url:         withOptions("https://example.com", `{"transcoding":{"width":640}}`),
+			filepath:    "recording.mp4",
+			expectedErr: true,
+		},
+		{
+			name:        "invalid",
+			url:         withOptions("https://example.com", `{"track_transcoding":{"width":-1}}`),
+			filepath:    "recording.mp4",
+			expectedErr: true,
+		},
+		{
+			name:        "loudness",
+			url:         withOptions("https://example.com", `{"audio_processing":{"target_loudness":-2}}`),
+			filepath:    "recording.mp4",
+			expectedErr: true,
+		},
+		{
+			name:        "scaling",
+			url:         withOptions("https://example.com", `{"video_scaling":{"mode":"zoom"}}`),
+			filepath:    "recording.mp4",
+			expectedErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			req := &rpc.StartEgressRequest{
+				EgressId: "egress_ID",
+				Request: &rpc.StartEgressRequest_Web{
+					Web: &livekit.WebEgressRequest{
+						Url: test.url,
+						FileOutputs: []*livekit.EncodedFileOutput{{
+							Filepath: test.filepath,
+						}},
+					},
+				},
+			}
+
+			p, err := GetValidatedPipelineConfig(conf, req)
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, int32(640), p.TrackTranscoding.Width)
+
+			// options are removed from the request, the result, and the source
+			web := p.Info.Request.(*livekit.EgressInfo_Web).Web
+			require.Equal(t, "https://example.com", web.Url)
+			require.Equal(t, "recording.mp4", web.FileOutputs[0].Filepath)
+			require.Equal(t, "https://example.com", p.WebUrl)
+			require.Equal(t, "recording.mp4", p.GetFileConfig().StorageFilepath)
+
+			// the original request is unchanged, for the handler
+			require.Equal(t, test.url, req.GetWeb().Url)
+		})
+	}
+}
+
+func TestVideoCodecOption(t *testing.T) {
+	conf := &ServiceConfig{
+		BaseConfig: BaseConfig{
+			ApiKey:    "key",
+			ApiSecret: "secret",
+			WsUrl:     "wss://localhost:7880",
+		},
+	}
+
+	for _, test := range []struct {
+		codec         string
+		segments      bool
+		expectedCodec types.MimeType
+		expectedErr   bool
+	}{
+		{codec: "h265", expectedCodec: types.MimeTypeH265},
+		{codec: "av1", expectedCodec: types.MimeTypeAV1},
+		{codec: "h265", segments: true, expectedCodec: types.MimeTypeH265},
+		{codec: "h266", expectedErr: true},
+	} {
+		options := "#lk_egress=" + url.PathEscape(`{"video_codec":"`+test.codec+`"}`)
+		req := &livekit.TrackCompositeEgressRequest{
+			RoomName:     "room",
+			AudioTrackId: "audio_track",
+			VideoTrackId: "video_track",
+		}
+		if test.segments {
+			req.SegmentOutputs = []*livekit.SegmentedFileOutput{{FilenamePrefix: "segments" + options}}
+		} else {
+			req.FileOutputs = []*livekit.EncodedFileOutput{{Filepath: "recording.mkv" + options}}
+		}
+
+		p, err := GetValidatedPipelineConfig(conf, &rpc.StartEgressRequest{
+			EgressId: "egress_ID",
+			Request: &rpc.StartEgressRequest_TrackComposite{
+				TrackComposite: req,
+			},
+		})
+		if test.expectedErr {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, test.expectedCodec, p.VideoOutCodec)
+	}
+}
+
+func TestAV1EncoderOption(t *testing.T) {
+	conf := &ServiceConfig{
+		BaseConfig: BaseConfig{
+			ApiKey:    "key",
+			ApiSecret: "secret",
+			WsUrl:     "wss://localhost:7880",
+		},
+	}
+
+	for _, test := range []struct {
+		encoder     string
+		expectedErr bool
+	}{
+		{encoder: "av1enc"},
+		{encoder: "svtav1enc"},
+		{encoder: "x264enc", expectedErr: true},
+	} {
+		options := "#lk_egress=" + url.PathEscape(`{"video_codec":"av1","av1_encoder":"`+test.encoder+`"}`)
+		p, err := GetValidatedPipelineConfig(conf, &rpc.StartEgressRequest{
+			EgressId: "egress_ID",
+			Request: &rpc.StartEgressRequest_TrackComposite{
+				TrackComposite: &livekit.TrackCompositeEgressRequest{
+					RoomName:     "room",
+					AudioTrackId: "audio_track",
+					VideoTrackId: "video_track",
+					FileOutputs:  []*livekit.EncodedFileOutput{{Filepath: "recording.mkv" + options}},
+				},
+			},
+		})
+		if test.expectedErr {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, test.encoder, p.AV1Encoder)
+	}
+}
+
+func TestAudioFileOption(t *testing.T) {
+	conf := &ServiceConfig{
+		BaseConfig: BaseConfig{
+			ApiKey:    "key",
+			ApiSecret: "secret",
+			WsUrl:     "wss://localhost:7880",
+		},
+	}
+
+	for _, test := range []struct {
+		filepath         string
+		options          string
+		expectedRate     int32
+		expectedChannels int32
+		expectedErr      bool
+	}{
+		{filepath: "recording.mp3", options: `{"audio_file":{"sample_rate":22050,"channels":1}}`, expectedRate: 22050, expectedChannels: 1},
+		{filepath: "recording.flac", options: `{"audio_file":{"sample_rate":96000,"channels":6}}`, expectedRate: 96000, expectedChannels: 6},
+		{filepath: "recording.wav", options: `{"audio_file":{"channels":8}}`, expectedChannels: 8},
+		{filepath: "recording.mp3", options: `{"audio_file":{"channels":6}}`, expectedErr: true},
+		{filepath: "recording.mp3", options: `{"audio_file":{"sample_rate":96000}}`, expectedErr: true},
+		{filepath: "recording.wav", options: `{"audio_file":{"channels":-1}}`, expectedErr: true},
+	} {
+		p, err := GetValidatedPipelineConfig(conf, &rpc.StartEgressRequest{
+			EgressId: "egress_ID",
+			Request: &rpc.StartEgressRequest_TrackComposite{
+				TrackComposite: &livekit.TrackCompositeEgressRequest{
+					RoomName:     "room",
+					AudioTrackId: "audio_track",
+					FileOutputs: []*livekit.EncodedFileOutput{{
+						Filepath: test.filepath + "#lk_egress=" + url.PathEscape(test.options),
+					}},
+				},
+			},
+		})
+		if test.expectedErr {
+			require.Error(t, err, test.options)
+			continue
+		}
+		require.NoError(t, err, test.options)
+		if test.expectedRate != 0 {
+			require.Equal(t, test.expectedRate, p.AudioFrequency)
+		}
+		require.Equal(t, test.expectedChannels, p.AudioChannels)
+	}
+}
+
+func TestOverlayOptions(t *testing.T) {
+	conf := &ServiceConfig{
+		BaseConfig: BaseConfig{
+			ApiKey:    "key",
+			ApiSecret: "secret",
+			WsUrl:     "wss://localhost:7880",
+		},
+	}
+
+	for _, test := range []struct {
+		name        string
+		options     string
+		enabled     []types.EgressType
+		disabled    []types.EgressType
+		expectedErr bool
+	}{
+		{
+			name:     "default",
+			options:  `{"overlays":{"text":[{"text":"{room_name}"}]}}`,
+			enabled:  []types.EgressType{types.EgressTypeFile, types.EgressTypeStream, types.EgressTypeSegments},
+			disabled: []types.EgressType{types.EgressTypeImages},
+		},
+		{
+			name:     "stream only",
+			options:  `{"overlays":{"clock":{},"outputs":["stream","images"]}}`,
+			enabled:  []types.EgressType{types.EgressTypeStream, types.EgressTypeImages},
+			disabled: []types.EgressType{types.EgressTypeFile, types.EgressTypeSegments},
+		},
+		{
+			name:     "no overlays",
+			options:  `{"overlays":{"outputs":["file"]}}`,
+			disabled: []types.EgressType{types.EgressTypeFile},
+		},
+		{
+			name:        "local image",
+			options:     `{"overlays":{"images":[{"url":"/etc/logo.png"}]}}`,
+			expectedErr: true,
+		},
+		{
+			name:        "output",
+			options:     `{"overlays":{"clock":{},"outputs":["websocket"]}}`,
+			expectedErr: true,
+		},
+		{
+			name:        "align",
+			options:     `{"overlays":{"text":[{"text":"live","halign":"middle"}]}}`,
+			expectedErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := GetValidatedPipelineConfig(conf, &rpc.StartEgressRequest{
+				EgressId: "egress_ID",
+				Request: &rpc.StartEgressRequest_Web{
+					Web: &livekit.WebEgressRequest{
+						Url: "https://example.com#lk_egress=" + url.PathEscape(test.options),
+						FileOutputs: []*livekit.EncodedFileOutput{{
+							Filepath: "recording.mp4",
+						}},
+					},
+				},
+			})
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for _, egressType := range test.enabled {
+				require.True(t, p.Overlays.EnabledFor(egressType), egressType)
+			}
+			for _, egressType := range test.disabled {
+				require.False(t, p.Overlays.EnabledFor(egressType), egressType)
+			}
+		})
+	}
+}
+
+func TestImageSpritesOption(t *testing.T) {
+	conf := &ServiceConfig{
+		BaseConfig: BaseConfig{
+			ApiKey:    "key",
+			ApiSecret: "secret",
+			WsUrl:     "wss://localhost:7880",
+		},
+	}
+	withSprites := func(location, options string) string {
+		return location + "#lk_egress=" + url.PathEscape(options)
+	}
+
+	for _, test := range []struct {
+		name            string
+		url             string
+		prefixes        []string
+		expectedSprites []bool
+		expectedErr     bool
+	}{
+		{
+			name:            "one output",
+			url:             "https://example.com",
+			prefixes:        []string{withSprites("sprites", `{"sprites":{"columns":5}}`), "thumbnails"},
+			expectedSprites: []bool{true, false},
+		},
+		{
+			name:            "each output",
+			url:             "https://example.com",
+			prefixes:        []string{withSprites("a", `{"sprites":{}}`), withSprites("b.png", `{"sprites":{}}`)},
+			expectedSprites: []bool{true, true},
+		},
+		{
+			name:        "webp",
+			url:         "https://example.com",
+			prefixes:    []string{withSprites("sprites.webp", `{"sprites":{}}`)},
+			expectedErr: true,
+		},
+		{
+			name:        "negative",
+			url:         "https://example.com",
+			prefixes:    []string{withSprites("sprites", `{"sprites":{"rows":-1}}`)},
+			expectedErr: true,
+		},
+		{
+			name:        "not an image output",
+			url:         withSprites("https://example.com", `{"sprites":{}}`),
+			prefixes:    []string{"sprites"},
+			expectedErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			web := &livekit.WebEgressRequest{Url: test.url}
+			for _, prefix := range test.prefixes {
+				web.ImageOutputs = append(web.ImageOutputs, &livekit.ImageOutput{FilenamePrefix: prefix})
+			}
+
+			p, err := GetValidatedPipelineConfig(conf, &rpc.StartEgressRequest{
+				EgressId: "egress_ID",
+				Request:  &rpc.StartEgressRequest_Web{Web: web},
+			})
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			images := p.GetImageConfigs()
+			require.Len(t, images, len(test.expectedSprites))
+			for i, c := range images {
+				require.Equal(t, test.expectedSprites[i], c.Sprites != nil)
+				if c.Sprites != nil {
+					require.Equal(t, int32(10), c.Sprites.Rows)
+					require.Equal(t, c.Sprites.TileWidth, c.Width)
+				}
+			}
+		})
+	}
+}
+
+func TestSpeakingTimelineOption(t *testing.T) {
+	conf := &ServiceConfig{
+		BaseConfig: BaseConfig{
+			ApiKey:                 "key",
+			ApiSecret:              "secret",
+			WsUrl:                  "wss://localhost:7880",
+			EnableSDKRoomComposite: true,
+		},
+	}
+
+	for _, options := range []string{"", `{"speaking_timeline":true}`} {
+		filepath := "recording.ogg"
+		if options != "" {
+			filepath += "#lk_egress=" + url.PathEscape(options)
+		}
+		p, err := GetValidatedPipelineConfig(conf, &rpc.StartEgressRequest{
+			EgressId: "egress_ID",
+			Request: &rpc.StartEgressRequest_RoomComposite{
+				RoomComposite: &livekit.RoomCompositeEgressRequest{
+					RoomName:    "room",
+					AudioOnly:   true,
+					FileOutputs: []*livekit.EncodedFileOutput{{Filepath: filepath}},
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, options != "", p.Speakers != nil)
+	}
+}
+
+func TestImagePreviewOption(t *testing.T) {
+	conf := &ServiceConfig{
+		BaseConfig: BaseConfig{
+			ApiKey:    "key",
+			ApiSecret: "secret",
+			WsUrl:     "wss://localhost:7880",
+		},
+	}
+	preview := "#lk_egress=" + url.PathEscape(`{"image_preview":{"format":"gif"}}`)
+
+	for _, test := range []struct {
+		name           string
+		url            string
+		filepath       string
+		expectedImages int
+		expectedErr    bool
+	}{
+		{name: "none", url: "https://example.com", filepath: "recording.mp4"},
+		{name: "file", url: "https://example.com", filepath: "recording.mp4" + preview, expectedImages: 1},
+		{name: "url", url: "https://example.com" + preview, filepath: "recording.mp4", expectedErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := GetValidatedPipelineConfig(conf, &rpc.StartEgressRequest{
+				EgressId: "egress_ID",
+				Request: &rpc.StartEgressRequest_Web{
+					Web: &livekit.WebEgressRequest{
+						Url:         test.url,
+						FileOutputs: []*livekit.EncodedFileOutput{{Filepath: test.filepath}},
+					},
+				},
+			})
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, p.Outputs[types.EgressTypeImages], test.expectedImages)
+			if test.expectedImages > 0 {
+				require.Equal(t, types.OutputTypeGIF, p.Outputs[types.EgressTypeImages][0].GetOutputType())
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/livekit/egress/pkg/types"
 	"github.com/livekit/protocol/egress"
 	"github.com/livekit/protocol/livekit"
+	lksdk "github.com/livekit/server-sdk-go/v2"
 )
 
 const StreamKeyframeInterval = 4.0
@@ -235,6 +236,56 @@ func (p *PipelineConfig) updateDirectOutput(req *livekit.TrackEgressRequest) err
 	return nil
 }
 
+// UpdateTrackOutput sets the file type for track egress once the track codec is known.
+// The track is only transcoded if the requested file extension does not match its codec.
+func (p *PipelineConfig) UpdateTrackOutput(ts *TrackSource) error {
+	o := p.GetFileConfig()
+	if o == nil {
+		return nil
+	}
+
+	if ts.Kind == lksdk.TrackKindAudio && p.TrackTranscoding.AudioBitrate > 0 {
+		p.AudioBitrate = p.TrackTranscoding.AudioBitrate
+	}
+
+	outputType := types.TrackOutputTypes[ts.MimeType]
+	requested, ok := types.OutputTypeForFileExtension[getFileExtension(o.StorageFilepath)]
+	if !ok || requested == outputType {
+		o.OutputType = outputType
+		return nil
+	}
+
+	switch ts.Kind {
+	case lksdk.TrackKindAudio:
+		codec, ok := types.DefaultAudioCodecs[requested]
+		if !ok {
+			return errors.ErrIncompatible(requested, ts.MimeType)
+		}
+		p.AudioOutCodec = codec
+
+	case lksdk.TrackKindVideo:
+		if !types.CodecCompatibility[requested][ts.MimeType] {
+			codec, ok := types.DefaultVideoCodecs[requested]
+			if !ok || !types.AllOutputVideoCodecs[codec] {
+				return errors.ErrIncompatible(requested, ts.MimeType)
+			}
+			p.VideoOutCodec = codec
+			if p.TrackTranscoding.Width > 0 {
+				p.Width = p.TrackTranscoding.Width
+			}
+			if p.TrackTranscoding.Height > 0 {
+				p.Height = p.TrackTranscoding.Height
+			}
+			if p.TrackTranscoding.VideoBitrate > 0 {
+				p.VideoBitrate = p.TrackTranscoding.VideoBitrate
+			}
+		}
+	}
+
+	o.OutputType = requested
+	return p.updateAudioFile()
+}
+
 func (p *PipelineConfig) updateImageOutputs(images []*livekit.ImageOutput) error {
 	if len(images) > 0 && !p.VideoEnabled {
 		return errors.ErrInvalidInput("audio_only")
@@ -253,5 +304,32 @@ func (p *PipelineConfig) updateImageOutputs(images []*livekit.ImageOutput) error
 		p.Info.ImageResults = append(p.Info.ImageResults, conf.ImagesInfo)
 	}
 
+	// previews are uploaded alongside the recording which requested them
+	for _, egressType := range []types.EgressType{types.EgressTypeFile, types.EgressTypeSegments} {
+		preview := p.imagePreviews[egressType]
+		if preview == nil {
+			continue
+		}
+		if !p.VideoEnabled {
+			return errors.ErrInvalidInput(optionsParam + ".image_preview")
+		}
+
+		var upload UploadConfig
+		if o := p.GetFileConfig(); egressType == types.EgressTypeFile && o != nil {
+			upload = o.UploadConfig
+		} else if o := p.GetSegmentConfig(); egressType == types.EgressTypeSegments && o != nil {
+			upload = o.UploadConfig
+		}
+
+		conf, err := p.getImagePreviewConfig(preview, upload)
+		if err != nil {
+			return err
+		}
+
+		p.Outputs[types.EgressTypeImages] = append(p.Outputs[types.EgressTypeImages], conf)
+		p.OutputCount.Inc()
+		p.Info.ImageResults = append(p.Info.ImageResults, conf.ImagesInfo)
+	}
+
 	return nil
 }
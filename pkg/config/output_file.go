@@ -35,6 +35,9 @@ type FileConfig struct {
 
 	DisableManifest bool
 	UploadConfig    UploadConfig
+
+	// length of the intro stitched before the recording, which shifts this file's timeline offsets
+	ContentOffset time.Duration
 }
 
 func (p *PipelineConfig) GetFileConfig() *FileConfig {
@@ -121,16 +124,18 @@ func (o *FileConfig) updateFilepath(p *PipelineConfig, identifier string, replac
 		// generate filepath
 		o.StorageFilepath = fmt.Sprintf("%s%s-%s%s", o.StorageFilepath, identifier, time.Now().Format("2006-01-02T150405"), ext)
 	} else if !strings.HasSuffix(o.StorageFilepath, string(ext)) {
-		// check for existing (incorrect) extension
-		if extIdx := strings.LastIndex(o.StorageFilepath, "."); extIdx > -1 {
-			existingExt := types.FileExtension(o.StorageFilepath[extIdx:])
+		existingExt := getFileExtension(o.StorageFilepath)
+
+		// keep alternate extensions for the same file type (.m4a)
+		if types.OutputTypeForFileExtension[existingExt] != o.OutputType {
+			// remove existing (incorrect) extension
 			if _, ok := types.FileExtensions[existingExt]; ok {
-				o.StorageFilepath = o.StorageFilepath[:extIdx]
+				o.StorageFilepath = strings.TrimSuffix(o.StorageFilepath, string(existingExt))
 			}
-		}
 
-		// add file extension
-		o.StorageFilepath = o.StorageFilepath + string(ext)
+			// add file extension
+			o.StorageFilepath = o.StorageFilepath + string(ext)
+		}
 	}
 
 	// update filename
@@ -163,6 +168,13 @@ func (o *FileConfig) updateFilepath(p *PipelineConfig, identifier string, replac
 	return nil
 }
 
+func getFileExtension(filepath string) types.FileExtension {
+	if extIdx := strings.LastIndex(filepath, "."); extIdx > -1 {
+		return types.FileExtension(filepath[extIdx:])
+	}
+	return ""
+}
+
 func clean(filepath string) string {
 	hasEndingSlash := strings.HasSuffix(filepath, "/")
 	filepath = path.Clean(filepath)
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 	"time"
 
 	"github.com/livekit/egress/pkg/errors"
@@ -45,6 +46,9 @@ type ImageConfig struct {
 	Width           int32
 	Height          int32
 	ImageOutCodec   types.MimeType
+	Sprites         *ImageSpritesConfig      // frames are tiled into sprite sheets when set
+	Preview         *ImagePreviewConfig      // frames are combined into an animated preview when set
+	SceneCapture    *ImageSceneCaptureConfig // frames are only kept when the scene changes when set
 }
 
 func (p *PipelineConfig) GetImageConfigs() []*ImageConfig {
@@ -59,12 +63,16 @@ func (p *PipelineConfig) GetImageConfigs() []*ImageConfig {
 }
 
 func (p *PipelineConfig) getImageConfig(images *livekit.ImageOutput) (*ImageConfig, error) {
-	outCodec, outputType, err := getMimeTypes(images.ImageCodec)
+	filenamePrefix := clean(images.FilenamePrefix)
+	ext := types.FileExtension(path.Ext(filenamePrefix))
+	outCodec, outputType, err := getMimeTypes(images.ImageCodec, ext)
 	if err != nil {
 		return nil, err
 	}
+	if _, ok := types.ImageCodecForFileExtension[ext]; ok {
+		filenamePrefix = strings.TrimSuffix(filenamePrefix, string(ext))
+	}
 
-	filenamePrefix := clean(images.FilenamePrefix)
 	conf := &ImageConfig{
 		outputConfig: outputConfig{
 			OutputType: outputType,
@@ -89,6 +97,47 @@ func (p *PipelineConfig) getImageConfig(images *livekit.ImageOutput) (*ImageConf
 		conf.CaptureInterval = 10
 	}
 
+	if sprites := p.imageSprites[images]; sprites != nil {
+		switch outCodec {
+		case types.MimeTypeJPEG, types.MimeTypePNG:
+		default:
+			// sheets are encoded by the sink
+			return nil, errors.ErrNotSupported(fmt.Sprintf("%s sprites", outCodec))
+		}
+		conf.Sprites = &ImageSpritesConfig{
+			Columns:    defaultInt32(sprites.Columns, 10),
+			Rows:       defaultInt32(sprites.Rows, 10),
+			TileWidth:  defaultInt32(sprites.TileWidth, 160),
+			TileHeight: defaultInt32(sprites.TileHeight, 90),
+		}
+		conf.Width = conf.Sprites.TileWidth
+		conf.Height = conf.Sprites.TileHeight
+	}
+
+	if sceneCapture := p.imageSceneCapture[images]; sceneCapture != nil {
+		if conf.Sprites != nil {
+			// thumbnail cues assume a fixed capture interval
+			return nil, errors.ErrNotSupported("scene capture with image sprites")
+		}
+		conf.SceneCapture = &ImageSceneCaptureConfig{
+			Threshold:   sceneCapture.Threshold,
+			MinInterval: sceneCapture.MinInterval,
+			MaxInterval: sceneCapture.MaxInterval,
+		}
+		if conf.SceneCapture.Threshold <= 0 {
+			conf.SceneCapture.Threshold = 0.02
+		}
+		if conf.SceneCapture.MinInterval == 0 {
+			conf.SceneCapture.MinInterval = time.Second
+		}
+		if conf.SceneCapture.MinInterval < 100*time.Millisecond {
+			return nil, errors.ErrInvalidInput(optionsParam + ".scene_capture.min_interval")
+		}
+		if conf.SceneCapture.MaxInterval != 0 && conf.SceneCapture.MaxInterval < conf.SceneCapture.MinInterval {
+			return nil, errors.ErrInvalidInput(optionsParam + ".scene_capture.max_interval")
+		}
+	}
+
 	// Set default dimensions for RoomComposite and Web. For all SDKs input, default will be
 	// set from the track dimensions
 	switch p.Info.Request.(type) {
@@ -147,11 +196,96 @@ func (o *ImageConfig) updatePrefix(p *PipelineConfig) error {
 
 	return nil
 }
-func getMimeTypes(imageCodec livekit.ImageCodec) (types.MimeType, types.OutputType, error) {
+
+// getMimeTypes uses the filename prefix extension when no codec is requested
+func getMimeTypes(imageCodec livekit.ImageCodec, ext types.FileExtension) (types.MimeType, types.OutputType, error) {
 	switch imageCodec {
-	case livekit.ImageCodec_IC_DEFAULT, livekit.ImageCodec_IC_JPEG:
+	case livekit.ImageCodec_IC_DEFAULT:
+		switch types.ImageCodecForFileExtension[ext] {
+		case types.MimeTypePNG:
+			return types.MimeTypePNG, types.OutputTypePNG, nil
+		case types.MimeTypeWebP:
+			return types.MimeTypeWebP, types.OutputTypeWebP, nil
+		default:
+			return types.MimeTypeJPEG, types.OutputTypeJPEG, nil
+		}
+	case livekit.ImageCodec_IC_JPEG:
 		return types.MimeTypeJPEG, types.OutputTypeJPEG, nil
 	default:
 		return "", "", errors.ErrNoCompatibleCodec
 	}
 }
+
+// getImagePreviewConfig captures jpeg frames, which are combined into the preview by the sink
+func (p *PipelineConfig) getImagePreviewConfig(requested *ImagePreviewConfig, upload UploadConfig) (*ImageConfig, error) {
+	preview := &ImagePreviewConfig{
+		Format:    requested.Format,
+		Width:     defaultInt32(requested.Width, 320),
+		Duration:  requested.Duration,
+		Window:    requested.Window,
+		Framerate: defaultInt32(requested.Framerate, 10),
+	}
+	if preview.Duration <= 0 {
+		preview.Duration = time.Second * 5
+	}
+	if preview.Window <= 0 {
+		preview.Window = time.Minute
+	}
+	if preview.Window < time.Second {
+		// frames are sampled at frames/seconds
+		return nil, errors.ErrInvalidInput(optionsParam + ".image_preview.window")
+	}
+	if preview.GetPreviewFrameCount() == 0 {
+		return nil, errors.ErrInvalidInput(optionsParam + ".image_preview.duration")
+	}
+
+	var outputType types.OutputType
+	switch preview.Format {
+	case "", "webp":
+		outputType = types.OutputTypeWebP
+	case "gif":
+		outputType = types.OutputTypeGIF
+	default:
+		return nil, errors.ErrInvalidInput(optionsParam + ".image_preview.format")
+	}
+
+	filenamePrefix := requested.FilenamePrefix
+	if filenamePrefix == "" {
+		filenamePrefix = "{room_name}-{time}-preview"
+	}
+
+	conf := &ImageConfig{
+		outputConfig: outputConfig{
+			OutputType: outputType,
+		},
+
+		Id: utils.NewGuid(""),
+		ImagesInfo: &livekit.ImagesInfo{
+			FilenamePrefix: filenamePrefix,
+		},
+		ImagePrefix:   filenamePrefix,
+		UploadConfig:  upload,
+		Width:         preview.Width,
+		ImageOutCodec: types.MimeTypeJPEG,
+		Preview:       preview,
+	}
+
+	if err := conf.updatePrefix(p); err != nil {
+		return nil, err
+	}
+	conf.ImageExtension = types.FileExtensionJPEG
+
+	return conf, nil
+}
+
+// GetPreviewFrameCount returns the number of frames sampled for the preview
+func (c *ImagePreviewConfig) GetPreviewFrameCount() int32 {
+	return int32(c.Duration.Seconds() * float64(c.Framerate))
+}
+
+func defaultInt32(v, d int32) int32 {
+	if v <= 0 {
+		return d
+	}
+	return v
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/egress/pkg/info"
+	"github.com/livekit/egress/pkg/types"
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestImageCodecFromPrefix(t *testing.T) {
+	for _, test := range []struct {
+		prefix             string
+		codec              livekit.ImageCodec
+		expectedCodec      types.MimeType
+		expectedOutputType types.OutputType
+	}{
+		{"thumbs/room", livekit.ImageCodec_IC_DEFAULT, types.MimeTypeJPEG, types.OutputTypeJPEG},
+		{"slides/room.png", livekit.ImageCodec_IC_DEFAULT, types.MimeTypePNG, types.OutputTypePNG},
+		{"thumbs/room.webp", livekit.ImageCodec_IC_DEFAULT, types.MimeTypeWebP, types.OutputTypeWebP},
+		{"thumbs/room.png", livekit.ImageCodec_IC_JPEG, types.MimeTypeJPEG, types.OutputTypeJPEG},
+	} {
+		codec, outputType, err := getMimeTypes(test.codec, types.FileExtension(path.Ext(test.prefix)))
+		require.NoError(t, err)
+		require.Equal(t, test.expectedCodec, codec, test.prefix)
+		require.Equal(t, test.expectedOutputType, outputType, test.prefix)
+	}
+}
+
+func TestImagePreviewConfig(t *testing.T) {
+	for _, test := range []struct {
+		name           string
+		preview        ImagePreviewConfig
+		expectedFrames int32
+		expectedType   types.OutputType
+		expectedErr    bool
+	}{
+		{
+			name:           "default",
+			expectedFrames: 50,
+			expectedType:   types.OutputTypeWebP,
+		},
+		{
+			name:           "gif",
+			preview:        ImagePreviewConfig{Format: "gif", Duration: 2 * time.Second, Framerate: 5},
+			expectedFrames: 10,
+			expectedType:   types.OutputTypeGIF,
+		},
+		{
+			name:        "format",
+			preview:     ImagePreviewConfig{Format: "apng"},
+			expectedErr: true,
+		},
+		{
+			name:        "short window",
+			preview:     ImagePreviewConfig{Window: 500 * time.Millisecond},
+			expectedErr: true,
+		},
+		{
+			name:        "no frames",
+			preview:     ImagePreviewConfig{Duration: 50 * time.Millisecond},
+			expectedErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p := &PipelineConfig{
+				Info: &info.EgressInfo{EgressId: "egress_ID", RoomName: "room"},
+			}
+
+			c, err := p.getImagePreviewConfig(&test.preview, nil)
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expectedType, c.OutputType)
+			require.Equal(t, types.MimeTypeJPEG, c.ImageOutCodec)
+			require.Equal(t, test.expectedFrames, c.Preview.GetPreviewFrameCount())
+		})
+	}
+}
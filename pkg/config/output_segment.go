@@ -21,6 +21,8 @@ import (
 	"strings"
 	"time"
 
+	"go.uber.org/atomic"
+
 	"github.com/livekit/egress/pkg/errors"
 	"github.com/livekit/egress/pkg/types"
 	"github.com/livekit/protocol/livekit"
@@ -40,6 +42,9 @@ type SegmentConfig struct {
 
 	DisableManifest bool
 	UploadConfig    UploadConfig
+
+	// length of the intro segments added before the live segments, which shifts this playlist's timeline offsets
+	ContentOffset atomic.Duration
 }
 
 func (p *PipelineConfig) GetSegmentConfig() *SegmentConfig {
@@ -50,6 +55,14 @@ func (p *PipelineConfig) GetSegmentConfig() *SegmentConfig {
 	return o[0].(*SegmentConfig)
 }
 
+// GetSegmentOutputType returns the segment file type. HLS requires fragmented mp4 segments for h265
+func (p *PipelineConfig) GetSegmentOutputType() types.OutputType {
+	if p.VideoEnabled && p.VideoOutCodec == types.MimeTypeH265 {
+		return types.OutputTypeM4S
+	}
+	return types.OutputTypeTS
+}
+
 // segments should always be added last, so we can check keyframe interval from file/stream
 func (p *PipelineConfig) getSegmentConfig(segments *livekit.SegmentedFileOutput) (*SegmentConfig, error) {
 	conf := &SegmentConfig{
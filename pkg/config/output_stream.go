@@ -74,7 +74,9 @@ func (p *PipelineConfig) getStreamConfig(outputType types.OutputType, urls []str
 
 	case types.OutputTypeSRT:
 		p.AudioOutCodec = types.MimeTypeAAC
-		p.VideoOutCodec = types.MimeTypeH264
+		if p.VideoOutCodec == "" {
+			p.VideoOutCodec = p.getDefaultVideoCodec(outputType)
+		}
 
 	case types.OutputTypeRaw:
 		p.AudioOutCodec = types.MimeTypeRawAudio
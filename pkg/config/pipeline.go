@@ -17,6 +17,7 @@ package config
 import (
 	"context"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -46,6 +47,7 @@ type PipelineConfig struct {
 	TmpDir    string `yaml:"tmp_dir"`
 
 	types.RequestType `yaml:"-"`
+	RequestOptions    `yaml:"-"`
 	SourceConfig      `yaml:"-"`
 	AudioConfig       `yaml:"-"`
 	VideoConfig       `yaml:"-"`
@@ -70,6 +72,7 @@ type WebSourceParams struct {
 	Token            string
 	BaseUrl          string
 	WebUrl           string
+	CaptureRegion    *CaptureRegion
 }
 
 type SDKSourceParams struct {
@@ -83,15 +86,22 @@ type SDKSourceParams struct {
 	VideoInCodec types.MimeType
 	AudioTrack   *TrackSource
 	VideoTrack   *TrackSource
+	AudioTracks  []*TrackSource // room composite
+	VideoTracks  []*TrackSource // room composite
+	DataRecorder *DataRecorder  // when data_capture is enabled
+	Quality      *QualityReport // a/v sync and packet loss per track
 }
 
 type TrackSource struct {
 	TrackID     string
+	Identity    string
 	Kind        lksdk.TrackKind
 	AppSrc      *app.Source
 	MimeType    types.MimeType
 	PayloadType webrtc.PayloadType
 	ClockRate   uint32
+	Width       uint32 // published video size, 0 if unknown
+	Height      uint32
 }
 
 type AudioConfig struct {
@@ -100,6 +110,13 @@ type AudioConfig struct {
 	AudioOutCodec    types.MimeType
 	AudioBitrate     int32
 	AudioFrequency   int32
+	AudioChannels    int32
+
+	// measured integrated loudness in LUFS, when audio processing is enabled
+	IntegratedLoudness atomic.Float64
+
+	// per-participant voice activity, when speaking_timeline is enabled
+	Speakers *SpeakingTimeline
 }
 
 type VideoConfig struct {
@@ -114,6 +131,9 @@ type VideoConfig struct {
 	Framerate        int32
 	VideoBitrate     int32
 	KeyFrameInterval float64
+
+	// received resolution changes, for sdk egress
+	VideoLayers *VideoLayerLog
 }
 
 func NewPipelineConfig(confString string, req *rpc.StartEgressRequest) (*PipelineConfig, error) {
@@ -159,6 +179,13 @@ func (p *PipelineConfig) Update(request *rpc.StartEgressRequest) error {
 		return errors.ErrInvalidInput("egressID")
 	}
 
+	// options are removed from the request before it's used or reported
+	request = proto.Clone(request).(*rpc.StartEgressRequest)
+	p.RequestOptions = RequestOptions{}
+	if err := p.updateRequestOptions(request); err != nil {
+		return err
+	}
+
 	// start with defaults
 	now := time.Now().UnixNano()
 	p.Info = &info.EgressInfo{
@@ -171,6 +198,7 @@ func (p *PipelineConfig) Update(request *rpc.StartEgressRequest) error {
 	p.AudioConfig = AudioConfig{
 		AudioBitrate:   128,
 		AudioFrequency: 44100,
+		AudioChannels:  2,
 	}
 	p.VideoConfig = VideoConfig{
 		VideoProfile: types.ProfileMain,
@@ -191,29 +219,47 @@ func (p *PipelineConfig) Update(request *rpc.StartEgressRequest) error {
 		}
 		egress.RedactEncodedOutputs(clone)
 
-		p.SourceType = types.SourceTypeWeb
-		p.AwaitStartSignal = true
-
 		p.Info.RoomName = req.RoomComposite.RoomName
 		p.Layout = req.RoomComposite.Layout
-		if req.RoomComposite.CustomBaseUrl != "" {
-			p.BaseUrl = req.RoomComposite.CustomBaseUrl
+
+		var err error
+		p.SourceType = p.GetRoomCompositeSourceType(req.RoomComposite)
+		if p.SourceType == types.SourceTypeSDK {
+			// subscribe to tracks directly, mixing and compositing without chrome
+			if !req.RoomComposite.AudioOnly {
+				p.Layout, _ = getSDKLayout(p.Layout)
+			}
 		} else {
-			p.BaseUrl = p.TemplateBase
-		}
-		baseUrl, err := url.Parse(p.BaseUrl)
-		if err != nil || (baseUrl.Scheme != "http" && baseUrl.Scheme != "https") {
-			return errors.ErrInvalidInput("template base url")
+			p.SourceType = types.SourceTypeWeb
+			p.AwaitStartSignal = true
+			// without the native compositor, render the matching template layout
+			p.Layout = strings.TrimPrefix(p.Layout, nativeLayoutPrefix)
+
+			if req.RoomComposite.CustomBaseUrl != "" {
+				p.BaseUrl = req.RoomComposite.CustomBaseUrl
+			} else {
+				p.BaseUrl = p.TemplateBase
+			}
+			baseUrl, err := url.Parse(p.BaseUrl)
+			if err != nil || (baseUrl.Scheme != "http" && baseUrl.Scheme != "https") {
+				return errors.ErrInvalidInput("template base url")
+			}
 		}
 
 		if !req.RoomComposite.VideoOnly {
 			p.AudioEnabled = true
-			p.AudioInCodec = types.MimeTypeRawAudio
+			if p.SourceType == types.SourceTypeSDK {
+				p.AudioInCodec = types.MimeTypeOpus
+			} else {
+				p.AudioInCodec = types.MimeTypeRawAudio
+			}
 			p.AudioTranscoding = true
 		}
 		if !req.RoomComposite.AudioOnly {
 			p.VideoEnabled = true
-			p.VideoInCodec = types.MimeTypeRawVideo
+			if p.SourceType == types.SourceTypeWeb {
+				p.VideoInCodec = types.MimeTypeRawVideo
+			}
 			p.VideoDecoding = true
 		}
 		if !p.AudioEnabled && !p.VideoEnabled {
@@ -378,7 +424,7 @@ func (p *PipelineConfig) Update(request *rpc.StartEgressRequest) error {
 		}
 
 		if err := p.updateDirectOutput(req.Track); err != nil {
-			return nil
+			return err
 		}
 
 	default:
@@ -412,6 +458,23 @@ func (p *PipelineConfig) Update(request *rpc.StartEgressRequest) error {
 		}
 	}
 
+	if p.SpeakingTimeline && p.SourceType == types.SourceTypeSDK && p.RequestType != types.RequestTypeTrack && p.AudioEnabled {
+		p.Speakers = NewSpeakingTimeline()
+	}
+	if p.DataCapture.Enabled() && (p.SourceType == types.SourceTypeSDK || p.DataCapture.CaptionSource != "") {
+		p.DataRecorder = NewDataRecorder(&p.DataCapture)
+	}
+	if p.SourceType == types.SourceTypeSDK && p.RequestType != types.RequestTypeTrack {
+		p.VideoLayers = NewVideoLayerLog()
+	}
+	if p.SourceType == types.SourceTypeSDK {
+		p.Quality = NewQualityReport()
+	}
+
+	if codec, ok := videoCodecNames[p.VideoCodec]; ok && p.VideoEnabled && p.RequestType != types.RequestTypeTrack {
+		p.VideoOutCodec = codec
+	}
+
 	if p.RequestType != types.RequestTypeTrack {
 		err := p.validateAndUpdateOutputParams()
 		if err != nil {
@@ -422,6 +485,41 @@ func (p *PipelineConfig) Update(request *rpc.StartEgressRequest) error {
 	return nil
 }
 
+// room composite layouts starting with this prefix opt in to the native compositor
+const nativeLayoutPrefix = "native-"
+
+// GetRoomCompositeSourceType returns SourceTypeSDK if the room composite can be recorded without chrome.
+// Request options are ignored, so admission and the pipeline pick the same source before and after they are taken
+func (c *BaseConfig) GetRoomCompositeSourceType(req *livekit.RoomCompositeEgressRequest) types.SourceType {
+	if !c.EnableSDKRoomComposite {
+		return types.SourceTypeWeb
+	}
+	if req.AudioOnly {
+		return types.SourceTypeSDK
+	}
+	if withoutOptions(req.CustomBaseUrl) == "" {
+		if _, ok := getSDKLayout(req.Layout); ok {
+			return types.SourceTypeSDK
+		}
+	}
+	return types.SourceTypeWeb
+}
+
+// getSDKLayout maps a native room composite layout to a compositor layout
+func getSDKLayout(layout string) (string, bool) {
+	if !strings.HasPrefix(layout, nativeLayoutPrefix) {
+		return "", false
+	}
+	layout = strings.TrimPrefix(layout, nativeLayoutPrefix)
+	layout = strings.TrimSuffix(strings.TrimSuffix(layout, "-light"), "-dark")
+	switch layout {
+	case types.LayoutGrid, types.LayoutSpeaker, types.LayoutSingleSpeaker:
+		return layout, true
+	default:
+		return "", false
+	}
+}
+
 func (p *PipelineConfig) validateAndUpdateOutputParams() error {
 	compatibleAudioCodecs, compatibleVideoCodecs, err := p.validateAndUpdateOutputCodecs()
 	if err != nil {
@@ -433,6 +531,9 @@ func (p *PipelineConfig) validateAndUpdateOutputParams() error {
 	if err != nil {
 		return err
 	}
+	if err = p.validateIntroOutro(); err != nil {
+		return err
+	}
 
 	// Select a codec compatible with all outputs
 	if p.AudioEnabled {
@@ -453,8 +554,8 @@ func (p *PipelineConfig) validateAndUpdateOutputParams() error {
 
 	if p.VideoEnabled {
 		for _, o := range p.GetEncodedOutputs() {
-			if compatibleVideoCodecs[types.DefaultVideoCodecs[o.GetOutputType()]] {
-				p.VideoOutCodec = types.DefaultVideoCodecs[o.GetOutputType()]
+			if codec := p.getDefaultVideoCodec(o.GetOutputType()); compatibleVideoCodecs[codec] {
+				p.VideoOutCodec = codec
 				break
 			}
 		}
@@ -522,24 +623,29 @@ func (p *PipelineConfig) updateOutputType(compatibleAudioCodecs map[types.MimeTy
 		return nil
 	}
 
+	var outputTypes []types.OutputType
 	if !p.VideoEnabled {
-		ot := types.GetOutputTypeCompatibleWithCodecs(types.AudioOnlyFileOutputTypes, compatibleAudioCodecs, nil)
-		if ot == types.OutputTypeUnknownFile {
-			return errors.ErrNoCompatibleFileOutputType
-		}
-		o.OutputType = ot
+		outputTypes = types.AudioOnlyFileOutputTypes
+		compatibleVideoCodecs = nil
 	} else if !p.AudioEnabled {
-		ot := types.GetOutputTypeCompatibleWithCodecs(types.VideoOnlyFileOutputTypes, nil, compatibleVideoCodecs)
-		if ot == types.OutputTypeUnknownFile {
-			return errors.ErrNoCompatibleFileOutputType
-		}
-		o.OutputType = ot
+		outputTypes = types.VideoOnlyFileOutputTypes
+		compatibleAudioCodecs = nil
 	} else {
-		ot := types.GetOutputTypeCompatibleWithCodecs(types.AudioVideoFileOutputTypes, compatibleAudioCodecs, compatibleVideoCodecs)
-		if ot == types.OutputTypeUnknownFile {
-			return errors.ErrNoCompatibleFileOutputType
-		}
-		o.OutputType = ot
+		outputTypes = types.AudioVideoFileOutputTypes
+	}
+
+	// prefer the file type matching the requested extension
+	if ot, ok := types.OutputTypeForFileExtension[getFileExtension(o.StorageFilepath)]; ok && slices.Contains(outputTypes, ot) {
+		outputTypes = append([]types.OutputType{ot}, outputTypes...)
+	}
+
+	ot := types.GetOutputTypeCompatibleWithCodecs(outputTypes, compatibleAudioCodecs, compatibleVideoCodecs)
+	if ot == types.OutputTypeUnknownFile {
+		return errors.ErrNoCompatibleFileOutputType
+	}
+	o.OutputType = ot
+	if err := p.updateAudioFile(); err != nil {
+		return err
 	}
 
 	identifier, replacements := p.getFilenameInfo()
@@ -551,8 +657,55 @@ func (p *PipelineConfig) updateOutputType(compatibleAudioCodecs map[types.MimeTy
 	return nil
 }
 
+// sample rates supported by lamemp3enc
+var mp3SampleRates = []int32{8000, 11025, 12000, 16000, 22050, 24000, 32000, 44100, 48000}
+
+// updateAudioFile applies the audio_file option to wav, flac and mp3 outputs, within the encoder's limits
+func (p *PipelineConfig) updateAudioFile() error {
+	o := p.GetFileConfig()
+	if o == nil {
+		return nil
+	}
+
+	maxChannels := int32(8)
+	switch o.OutputType {
+	case types.OutputTypeMP3:
+		maxChannels = 2
+	case types.OutputTypeWAV, types.OutputTypeFLAC:
+	default:
+		return nil
+	}
+
+	if p.AudioFile.SampleRate > 0 {
+		p.AudioFrequency = p.AudioFile.SampleRate
+	}
+	if p.AudioFile.Channels > 0 {
+		p.AudioChannels = p.AudioFile.Channels
+	}
+
+	switch {
+	case p.AudioChannels > maxChannels:
+		return errors.ErrInvalidInput(optionsParam + ".audio_file.channels")
+	case o.OutputType == types.OutputTypeMP3 && !slices.Contains(mp3SampleRates, p.AudioFrequency),
+		o.OutputType == types.OutputTypeFLAC && p.AudioFrequency > 655350,
+		p.AudioFrequency < 1:
+		return errors.ErrInvalidInput(optionsParam + ".audio_file.sample_rate")
+	}
+	return nil
+}
+
 // used for sdk input source
 func (p *PipelineConfig) UpdateInfoFromSDK(identifier string, replacements map[string]string, w, h uint32) error {
+	if p.RequestType == types.RequestTypeTrack && p.VideoEncoding {
+		// transcoded tracks keep their resolution unless configured
+		if p.TrackTranscoding.Width == 0 && w != 0 {
+			p.Width = int32(w)
+		}
+		if p.TrackTranscoding.Height == 0 && h != 0 {
+			p.Height = int32(h)
+		}
+	}
+
 	for egressType, c := range p.Outputs {
 		if len(c) == 0 {
 			continue
@@ -578,6 +731,10 @@ func (p *PipelineConfig) UpdateInfoFromSDK(identifier string, replacements map[s
 				o.StorageDir = stringReplace(o.StorageDir, replacements)
 				o.ImagePrefix = stringReplace(o.ImagePrefix, replacements)
 				o.ImagesInfo.FilenamePrefix = stringReplace(o.ImagesInfo.FilenamePrefix, replacements)
+				if o.Preview != nil {
+					// height follows the input aspect ratio
+					continue
+				}
 				if o.Width == 0 {
 					if w != 0 {
 						o.Width = int32(w)
@@ -609,6 +766,15 @@ func (p *PipelineConfig) GetEncodedOutputs() []OutputConfig {
 	return ret
 }
 
+// StreamsSlateBeforeStart reports whether a web egress streams the slate until the page is ready,
+// which only applies when every output is a stream
+func (p *PipelineConfig) StreamsSlateBeforeStart() bool {
+	if p.SourceType != types.SourceTypeWeb || p.Slate == nil || len(p.Outputs) != 1 {
+		return false
+	}
+	return len(p.Outputs[types.EgressTypeStream]) > 0
+}
+
 func stringReplace(s string, replacements map[string]string) string {
 	for template, value := range replacements {
 		s = strings.Replace(s, template, value, -1)
@@ -0,0 +1,116 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type TrackQuality struct {
+	TrackID    string
+	Kind       string
+	Identity   string
+	AvgDrift   time.Duration // a/v sync correction applied by the synchronizer
+	MaxDrift   time.Duration
+	PacketLoss float64 // fraction of packets dropped by the jitter buffer
+}
+
+// TrackQualityFunc returns the current stats of a track
+type TrackQualityFunc func() (avgDrift, maxDrift time.Duration, packetLoss float64)
+
+// QualityReport collects a/v sync and packet loss stats for each sdk track
+type QualityReport struct {
+	mu     sync.Mutex
+	tracks []*qualityTrack
+}
+
+type qualityTrack struct {
+	TrackQuality
+	stats TrackQualityFunc // nil once the track has finished
+}
+
+func NewQualityReport() *QualityReport {
+	return &QualityReport{}
+}
+
+// AddTrack polls stats until the returned finish func is called
+func (r *QualityReport) AddTrack(trackID, kind, identity string, stats TrackQualityFunc) func() {
+	t := &qualityTrack{
+		TrackQuality: TrackQuality{
+			TrackID:  trackID,
+			Kind:     kind,
+			Identity: identity,
+		},
+		stats: stats,
+	}
+
+	r.mu.Lock()
+	r.tracks = append(r.tracks, t)
+	r.mu.Unlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		t.update()
+		t.stats = nil
+	}
+}
+
+// Tracks returns every track subscribed during the egress, including resubscriptions
+func (r *QualityReport) Tracks() []TrackQuality {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tracks := make([]TrackQuality, 0, len(r.tracks))
+	for _, t := range r.tracks {
+		t.update()
+		tracks = append(tracks, t.TrackQuality)
+	}
+	return tracks
+}
+
+func (t *qualityTrack) update() {
+	if t.stats != nil {
+		t.AvgDrift, t.MaxDrift, t.PacketLoss = t.stats()
+	}
+}
+
+// Summary describes the worst tracks, e.g. "max a/v drift 120ms (TR_1 audio), max packet loss 0.50% (TR_2 video)"
+func (r *QualityReport) Summary() string {
+	var drift, loss *TrackQuality
+	tracks := r.Tracks()
+	for i := range tracks {
+		t := &tracks[i]
+		if drift == nil || t.MaxDrift > drift.MaxDrift {
+			drift = t
+		}
+		if loss == nil || t.PacketLoss > loss.PacketLoss {
+			loss = t
+		}
+	}
+	if drift == nil {
+		return ""
+	}
+
+	parts := []string{
+		fmt.Sprintf("max a/v drift %s (%s %s)", drift.MaxDrift.Round(time.Millisecond), drift.TrackID, drift.Kind),
+		fmt.Sprintf("max packet loss %.2f%% (%s %s)", loss.PacketLoss*100, loss.TrackID, loss.Kind),
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQualityReport(t *testing.T) {
+	r := NewQualityReport()
+	require.Empty(t, r.Tracks())
+	require.Equal(t, "", r.Summary())
+
+	drift, loss := 40*time.Millisecond, 0.001
+	finish := r.AddTrack("TR_1", "audio", "a", func() (time.Duration, time.Duration, float64) {
+		return drift / 2, drift, loss
+	})
+	r.AddTrack("TR_2", "video", "a", func() (time.Duration, time.Duration, float64) {
+		return 0, 10 * time.Millisecond, 0.02
+	})
+
+	// active tracks are polled
+	drift, loss = 80*time.Millisecond, 0.03
+	require.Equal(t, "max a/v drift 80ms (TR_1 audio), max packet loss 3.00% (TR_1 audio)", r.Summary())
+	tracks := r.Tracks()
+	require.Equal(t, 40*time.Millisecond, tracks[0].AvgDrift)
+	require.Equal(t, 0.03, tracks[0].PacketLoss)
+
+	// finished tracks keep their last stats
+	finish()
+	drift, loss = 200*time.Millisecond, 0.5
+	tracks = r.Tracks()
+	require.Equal(t, 80*time.Millisecond, tracks[0].MaxDrift)
+	require.Equal(t, 0.03, tracks[0].PacketLoss)
+
+	// resubscribed tracks are reported separately
+	r.AddTrack("TR_1", "audio", "a", func() (time.Duration, time.Duration, float64) {
+		return 0, 5 * time.Millisecond, 0
+	})
+	tracks = r.Tracks()
+	require.Len(t, tracks, 3)
+	require.Equal(t, "TR_1", tracks[2].TrackID)
+	require.Equal(t, 5*time.Millisecond, tracks[2].MaxDrift)
+
+	require.Equal(t, "max a/v drift 80ms (TR_1 audio), max packet loss 3.00% (TR_1 audio)", r.Summary())
+
+	r.AddTrack("TR_3", "video", "b", func() (time.Duration, time.Duration, float64) {
+		return 0, 0, 0.1
+	})
+	require.Equal(t, "max a/v drift 80ms (TR_1 audio), max packet loss 10.00% (TR_3 video)", r.Summary())
+}
@@ -17,6 +17,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 
 	"gopkg.in/yaml.v3"
 
@@ -28,15 +29,17 @@ import (
 const (
 	TmpDir = "/home/egress/tmp"
 
-	roomCompositeCpuCost      = 4
-	audioRoomCompositeCpuCost = 1
-	webCpuCost                = 4
-	audioWebCpuCost           = 1
-	participantCpuCost        = 2
-	trackCompositeCpuCost     = 1
-	trackCpuCost              = 0.5
-	maxCpuUtilization         = 0.8
-	maxConcurrentWeb          = 18
+	roomCompositeCpuCost         = 4
+	audioRoomCompositeCpuCost    = 1
+	sdkRoomCompositeCpuCost      = 3
+	sdkAudioRoomCompositeCpuCost = 0.5
+	webCpuCost                   = 4
+	audioWebCpuCost              = 1
+	participantCpuCost           = 2
+	trackCompositeCpuCost        = 1
+	trackCpuCost                 = 0.5
+	maxCpuUtilization            = 0.8
+	maxConcurrentWeb             = 18
 
 	defaultTemplatePort         = 7980
 	defaultTemplateBaseTemplate = "http://localhost:%d/"
@@ -54,15 +57,17 @@ type ServiceConfig struct {
 }
 
 type CPUCostConfig struct {
-	MaxCpuUtilization         float64 `yaml:"max_cpu_utilization"` // maximum allowed CPU utilization when deciding to accept a request. Default to 80%.
-	MaxConcurrentWeb          int32   `yaml:"max_concurrent_web"`  // maximum allowed chrome/x/pulse instances
-	RoomCompositeCpuCost      float64 `yaml:"room_composite_cpu_cost"`
-	AudioRoomCompositeCpuCost float64 `yaml:"audio_room_composite_cpu_cost"`
-	WebCpuCost                float64 `yaml:"web_cpu_cost"`
-	AudioWebCpuCost           float64 `yaml:"audio_web_cpu_cost"`
-	ParticipantCpuCost        float64 `yaml:"participant_cpu_cost"`
-	TrackCompositeCpuCost     float64 `yaml:"track_composite_cpu_cost"`
-	TrackCpuCost              float64 `yaml:"track_cpu_cost"`
+	MaxCpuUtilization            float64 `yaml:"max_cpu_utilization"` // maximum allowed CPU utilization when deciding to accept a request. Default to 80%.
+	MaxConcurrentWeb             int32   `yaml:"max_concurrent_web"`  // maximum allowed chrome/x/pulse instances
+	RoomCompositeCpuCost         float64 `yaml:"room_composite_cpu_cost"`
+	AudioRoomCompositeCpuCost    float64 `yaml:"audio_room_composite_cpu_cost"`
+	SDKRoomCompositeCpuCost      float64 `yaml:"sdk_room_composite_cpu_cost"`       // used when enable_sdk_room_composite is set
+	SDKAudioRoomCompositeCpuCost float64 `yaml:"sdk_audio_room_composite_cpu_cost"` // used when enable_sdk_room_composite is set
+	WebCpuCost                   float64 `yaml:"web_cpu_cost"`
+	AudioWebCpuCost              float64 `yaml:"audio_web_cpu_cost"`
+	ParticipantCpuCost           float64 `yaml:"participant_cpu_cost"`
+	TrackCompositeCpuCost        float64 `yaml:"track_composite_cpu_cost"`
+	TrackCpuCost                 float64 `yaml:"track_cpu_cost"`
 }
 
 func NewServiceConfig(confString string) (*ServiceConfig, error) {
@@ -94,6 +99,12 @@ func NewServiceConfig(confString string) (*ServiceConfig, error) {
 	if conf.AudioRoomCompositeCpuCost <= 0 {
 		conf.AudioRoomCompositeCpuCost = audioRoomCompositeCpuCost
 	}
+	if conf.SDKRoomCompositeCpuCost <= 0 {
+		conf.SDKRoomCompositeCpuCost = sdkRoomCompositeCpuCost
+	}
+	if conf.SDKAudioRoomCompositeCpuCost <= 0 {
+		conf.SDKAudioRoomCompositeCpuCost = sdkAudioRoomCompositeCpuCost
+	}
 	if conf.WebCpuCost <= 0 {
 		conf.WebCpuCost = webCpuCost
 	}
@@ -116,6 +127,22 @@ func NewServiceConfig(confString string) (*ServiceConfig, error) {
 		conf.MaxConcurrentWeb = maxConcurrentWeb
 	}
 
+	if conf.DefaultVideoCodec != "" {
+		if _, ok := videoCodecNames[conf.DefaultVideoCodec]; !ok {
+			return nil, errors.ErrInvalidInput("default_video_codec")
+		}
+	}
+
+	if err := conf.Slate.validate(); err != nil {
+		return nil, err
+	}
+	if err := conf.ChromeRecovery.validate(); err != nil {
+		return nil, err
+	}
+	if conf.ClipsDir != "" && !path.IsAbs(conf.ClipsDir) {
+		return nil, errors.ErrInvalidInput("clips_dir")
+	}
+
 	if conf.TemplateBase == "" {
 		conf.TemplateBase = fmt.Sprintf(defaultTemplateBaseTemplate, conf.TemplatePort)
 	}
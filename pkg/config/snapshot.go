@@ -0,0 +1,74 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/types"
+)
+
+// GetSnapshotOutputType returns the image codec for an on-demand snapshot
+func GetSnapshotOutputType(format string) (types.MimeType, types.OutputType, error) {
+	switch strings.ToLower(format) {
+	case "", "jpeg", "jpg":
+		return types.MimeTypeJPEG, types.OutputTypeJPEG, nil
+	case "png":
+		return types.MimeTypePNG, types.OutputTypePNG, nil
+	default:
+		return "", "", errors.ErrInvalidInput("format")
+	}
+}
+
+// GetSnapshotFilepath fills in filename templates and the extension, using the egress outputs' naming.
+// The snapshot is written relative to the handler's tmp dir, so absolute paths and parent directories are rejected
+func (p *PipelineConfig) GetSnapshotFilepath(filepath string, mimeType types.MimeType, outputType types.OutputType) (string, error) {
+	identifier, replacements := p.getFilenameInfo()
+	filepath = stringReplace(filepath, replacements)
+	if path.IsAbs(filepath) || slices.Contains(strings.Split(filepath, "/"), "..") {
+		return "", errors.ErrInvalidInput("filepath")
+	}
+	filepath = clean(filepath)
+
+	ext := types.FileExtensionForOutputType[outputType]
+	if filepath == "" || strings.HasSuffix(filepath, "/") {
+		return fmt.Sprintf("%s%s-snapshot-%s%s", filepath, identifier, time.Now().Format("2006-01-02T150405"), ext), nil
+	}
+	if types.ImageCodecForFileExtension[getFileExtension(filepath)] != mimeType {
+		filepath += string(ext)
+	}
+	return filepath, nil
+}
+
+// GetSnapshotUploadConfig uses the storage of the egress outputs, falling back to the service storage
+func (p *PipelineConfig) GetSnapshotUploadConfig() UploadConfig {
+	if o := p.GetFileConfig(); o != nil && o.UploadConfig != nil {
+		return o.UploadConfig
+	}
+	if o := p.GetSegmentConfig(); o != nil && o.UploadConfig != nil {
+		return o.UploadConfig
+	}
+	for _, o := range p.GetImageConfigs() {
+		if o.UploadConfig != nil {
+			return o.UploadConfig
+		}
+	}
+	return p.ToUploadConfig()
+}
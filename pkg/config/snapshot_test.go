@@ -0,0 +1,65 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/egress/pkg/info"
+)
+
+func TestSnapshotFilepath(t *testing.T) {
+	p := &PipelineConfig{Info: &info.EgressInfo{EgressId: "egress_ID", RoomName: "room"}}
+
+	for _, test := range []struct {
+		filepath    string
+		format      string
+		expected    string
+		expectedErr bool
+	}{
+		{filepath: "moderation/{room_name}.png", format: "png", expected: "moderation/room.png"},
+		{filepath: "moderation/frame", expected: "moderation/frame.jpeg"},
+		{filepath: "moderation/frame.jpg", format: "jpeg", expected: "moderation/frame.jpg"},
+		{filepath: "/etc/cron.d/frame", expectedErr: true},
+		{filepath: "moderation/../../frame", expectedErr: true},
+		{filepath: "{room_name}/frame", format: "png", expected: "room/frame.png"},
+	} {
+		mimeType, outputType, err := GetSnapshotOutputType(test.format)
+		require.NoError(t, err)
+		filepath, err := p.GetSnapshotFilepath(test.filepath, mimeType, outputType)
+		if test.expectedErr {
+			require.Error(t, err, test.filepath)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, test.expected, filepath)
+	}
+
+	mimeType, outputType, err := GetSnapshotOutputType("png")
+	require.NoError(t, err)
+	filepath, err := p.GetSnapshotFilepath("moderation/", mimeType, outputType)
+	require.NoError(t, err)
+	require.Regexp(t, `^moderation/room-snapshot-.*\.png$`, filepath)
+
+	// templates can't escape the tmp dir either
+	p.Info.RoomName = "../room"
+	_, err = p.GetSnapshotFilepath("{room_name}/frame", mimeType, outputType)
+	require.Error(t, err)
+
+	_, _, err = GetSnapshotOutputType("bmp")
+	require.Error(t, err)
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	speakingThreshold   = -45.0 // dBFS
+	speakingHangover    = time.Millisecond * 600
+	speakingMinDuration = time.Millisecond * 300
+)
+
+type SpeakingInterval struct {
+	Identity string
+	Start    time.Duration
+	End      time.Duration
+}
+
+// SpeakingTimeline tracks voice activity per participant, using audio levels measured before mixing
+type SpeakingTimeline struct {
+	mu        sync.Mutex
+	tracks    map[string]string // level element name to identity
+	open      map[string]*SpeakingInterval
+	intervals []*SpeakingInterval
+}
+
+func NewSpeakingTimeline() *SpeakingTimeline {
+	return &SpeakingTimeline{
+		tracks: make(map[string]string),
+		open:   make(map[string]*SpeakingInterval),
+	}
+}
+
+func (t *SpeakingTimeline) AddTrack(name, identity string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.tracks[name] = identity
+}
+
+// UpdateLevel records the loudest channel level (dBFS) measured by the named element
+func (t *SpeakingTimeline) UpdateLevel(name string, level float64, runningTime time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	identity, ok := t.tracks[name]
+	if !ok {
+		return
+	}
+
+	interval := t.open[identity]
+	switch {
+	case level >= speakingThreshold && interval == nil:
+		t.open[identity] = &SpeakingInterval{
+			Identity: identity,
+			Start:    runningTime,
+			End:      runningTime,
+		}
+	case level >= speakingThreshold:
+		interval.End = runningTime
+	case interval != nil && runningTime-interval.End > speakingHangover:
+		t.closeLocked(identity)
+	}
+}
+
+func (t *SpeakingTimeline) closeLocked(identity string) {
+	if interval := t.open[identity]; interval.End-interval.Start >= speakingMinDuration {
+		t.intervals = append(t.intervals, interval)
+	}
+	delete(t.open, identity)
+}
+
+// Close ends any ongoing intervals and returns the timeline, ordered by start time
+func (t *SpeakingTimeline) Close() []SpeakingInterval {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for identity := range t.open {
+		t.closeLocked(identity)
+	}
+
+	intervals := make([]SpeakingInterval, 0, len(t.intervals))
+	for _, interval := range t.intervals {
+		intervals = append(intervals, *interval)
+	}
+	sort.SliceStable(intervals, func(i, j int) bool {
+		return intervals[i].Start < intervals[j].Start
+	})
+	return intervals
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"time"
+
+	"github.com/livekit/egress/pkg/errors"
+)
+
+// WebConfig is applied before loading web egress pages and room composite templates
+type WebConfig struct {
+	Headers        map[string]string `yaml:"headers"`         // sent with requests to the page origin
+	Cookies        []*WebCookie      `yaml:"cookies"`         // set before navigation
+	LocalStorage   map[string]string `yaml:"local_storage"`   // seeded for the page origin before the first page load
+	SessionStorage map[string]string `yaml:"session_storage"` // seeded for the page origin before the first page load
+	Stylesheet     string            `yaml:"stylesheet"`      // css injected into every document
+	Script         string            `yaml:"script"`          // javascript evaluated in every document before page scripts
+	WaitFor        *WebWaitCondition `yaml:"wait_for"`        // recording starts once satisfied, and after START_RECORDING if awaited
+	Capture        *WebCaptureConfig `yaml:"capture"`         // record only part of the page
+}
+
+type WebCookie struct {
+	Name     string `yaml:"name"`
+	Value    string `yaml:"value"`
+	Domain   string `yaml:"domain"` // defaults to the page host
+	Path     string `yaml:"path"`   // default /
+	Secure   bool   `yaml:"secure"`
+	HttpOnly bool   `yaml:"http_only"`
+}
+
+type WebWaitCondition struct {
+	Selector    string        `yaml:"selector"`     // element must be visible
+	Expression  string        `yaml:"expression"`   // javascript expression must be truthy
+	NetworkIdle bool          `yaml:"network_idle"` // no requests in flight for 500ms
+	Timeout     time.Duration `yaml:"timeout"`      // recording starts anyway after this (default 30s)
+}
+
+type WebCaptureConfig struct {
+	Selector string `yaml:"selector"` // crop to this element, measured once when the page first loads
+	Fill     bool   `yaml:"fill"`     // lay the element out to fill the page instead of cropping
+	X        int32  `yaml:"x"`        // explicit region in page pixels, used without a selector
+	Y        int32  `yaml:"y"`
+	Width    int32  `yaml:"width"`
+	Height   int32  `yaml:"height"`
+}
+
+func (c *WebConfig) validate() error {
+	for _, cookie := range c.Cookies {
+		if cookie.Name == "" {
+			return errors.ErrInvalidInput(optionsParam + ".web.cookies.name")
+		}
+	}
+	if w := c.WaitFor; w != nil {
+		if w.Selector == "" && w.Expression == "" && !w.NetworkIdle {
+			return errors.ErrInvalidInput(optionsParam + ".web.wait_for")
+		}
+		if w.Timeout < 0 {
+			return errors.ErrInvalidInput(optionsParam + ".web.wait_for.timeout")
+		}
+	}
+	if capture := c.Capture; capture != nil {
+		if capture.Selector == "" && (capture.Fill || capture.Width <= 0 || capture.Height <= 0) {
+			return errors.ErrInvalidInput(optionsParam + ".web.capture")
+		}
+		if capture.X < 0 || capture.Y < 0 {
+			return errors.ErrInvalidInput(optionsParam + ".web.capture")
+		}
+	}
+	return nil
+}
+
+// GetTimeout returns the wait timeout with the default applied
+func (w *WebWaitCondition) GetTimeout() time.Duration {
+	if w.Timeout == 0 {
+		return time.Second * 30
+	}
+	return w.Timeout
+}
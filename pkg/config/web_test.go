@@ -0,0 +1,75 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+)
+
+func TestWebOptions(t *testing.T) {
+	conf := &ServiceConfig{
+		BaseConfig: BaseConfig{
+			ApiKey:    "key",
+			ApiSecret: "secret",
+			WsUrl:     "wss://localhost:7880",
+		},
+	}
+	newRequest := func(options string) *rpc.StartEgressRequest {
+		return &rpc.StartEgressRequest{
+			EgressId: "egress_ID",
+			Request: &rpc.StartEgressRequest_Web{
+				Web: &livekit.WebEgressRequest{
+					Url:         "https://dashboard.example.com/live#lk_egress=" + url.PathEscape(options),
+					FileOutputs: []*livekit.EncodedFileOutput{{Filepath: "recording.mp4"}},
+				},
+			},
+		}
+	}
+
+	p, err := GetValidatedPipelineConfig(conf, newRequest(`{"web":{
+		"headers":{"Authorization":"Bearer secret-token"},
+		"cookies":[{"name":"session","value":"secret-cookie"}],
+		"local_storage":{"token":"secret-storage"}
+	}}`))
+	require.NoError(t, err)
+	require.Equal(t, "Bearer secret-token", p.Web.Headers["Authorization"])
+	require.Equal(t, "secret-cookie", p.Web.Cookies[0].Value)
+	require.Equal(t, "secret-storage", p.Web.LocalStorage["token"])
+
+	// values are removed from the url before it's used or reported
+	require.Equal(t, "https://dashboard.example.com/live", p.WebUrl)
+	require.Equal(t, "https://dashboard.example.com/live", p.Info.Request.(*livekit.EgressInfo_Web).Web.Url)
+
+	_, err = GetValidatedPipelineConfig(conf, newRequest(`{"web":{"cookies":[{"value":"v"}]}}`))
+	require.Error(t, err)
+	_, err = GetValidatedPipelineConfig(conf, newRequest(`{"web":{"wait_for":{}}}`))
+	require.Error(t, err)
+
+	p, err = GetValidatedPipelineConfig(conf, newRequest(`{"web":{
+		"stylesheet":"body { overflow: hidden }",
+		"wait_for":{"network_idle":true}
+	}}`))
+	require.NoError(t, err)
+	require.Equal(t, "body { overflow: hidden }", p.Web.Stylesheet)
+	require.Zero(t, p.Web.WaitFor.Timeout)
+	require.Equal(t, time.Second*30, p.Web.WaitFor.GetTimeout())
+}
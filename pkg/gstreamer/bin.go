@@ -46,6 +46,7 @@ type Bin struct {
 	added    bool
 	srcs     []*Bin                   // source bins
 	elements []*gst.Element           // elements within this bin
+	branches [][]*gst.Element         // elements linked from a tee within this bin
 	queues   map[string]*gst.Element  // used with BinTypeMultiStream
 	pads     map[string]*gst.GhostPad // ghost pads by bin name
 	sinks    []*Bin                   // sink bins
@@ -151,6 +152,19 @@ func (b *Bin) AddElements(elements ...*gst.Element) error {
 	return nil
 }
 
+// Add elements linked from a tee (or another element with request pads) already added to this bin,
+// instead of to the end of the bin
+func (b *Bin) AddBranch(tee *gst.Element, elements ...*gst.Element) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.branches = append(b.branches, append([]*gst.Element{tee}, elements...))
+	if err := b.bin.AddMany(elements...); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	return nil
+}
+
 func (b *Bin) RemoveSourceBin(name string) error {
 	logger.Debugw(fmt.Sprintf("removing src %s from %s", name, b.bin.GetName()))
 	return b.removeBin(name, gst.PadDirectionSource)
@@ -409,6 +423,11 @@ func (b *Bin) link() error {
 				return errors.ErrGstPipelineError(err)
 			}
 		}
+		for _, branch := range b.branches {
+			if err := gst.ElementLinkMany(branch...); err != nil {
+				return errors.ErrGstPipelineError(err)
+			}
+		}
 
 		for _, src := range getPeerSrcs(b.srcs) {
 			src.mu.Lock()
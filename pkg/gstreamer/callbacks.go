@@ -37,6 +37,7 @@ type Callbacks struct {
 	onTrackMuted   []func(string)
 	onTrackUnmuted []func(string)
 	onTrackRemoved []func(string)
+	onSpeakers     []func([]string)
 	onEOSSent      func()
 
 	// internal
@@ -142,6 +143,22 @@ func (c *Callbacks) OnTrackRemoved(trackID string) {
 	}
 }
 
+func (c *Callbacks) AddOnActiveSpeakersChanged(f func([]string)) {
+	c.mu.Lock()
+	c.onSpeakers = append(c.onSpeakers, f)
+	c.mu.Unlock()
+}
+
+func (c *Callbacks) OnActiveSpeakersChanged(identities []string) {
+	c.mu.RLock()
+	onSpeakers := c.onSpeakers
+	c.mu.RUnlock()
+
+	for _, f := range onSpeakers {
+		f(identities)
+	}
+}
+
 func (c *Callbacks) SetOnEOSSent(f func()) {
 	c.mu.Lock()
 	c.onEOSSent = f
@@ -69,6 +69,22 @@ func (h *Handler) GetPProf(ctx context.Context, req *ipc.PProfRequest) (*ipc.PPr
 	}, nil
 }
 
+func (h *Handler) GetSnapshot(ctx context.Context, req *ipc.SnapshotRequest) (*ipc.SnapshotResponse, error) {
+	ctx, span := tracer.Start(ctx, "Handler.GetSnapshot")
+	defer span.End()
+
+	<-h.initialized.Watch()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	res, err := h.controller.GetSnapshot(ctx, req)
+	if err != nil && ctx.Err() != nil {
+		return nil, status.New(codes.DeadlineExceeded, "timed out requesting snapshot").Err()
+	}
+	return res, err
+}
+
 // GetMetrics implement the handler-side gathering of metrics to return over IPC
 func (h *Handler) GetMetrics(ctx context.Context, _ *ipc.MetricsRequest) (*ipc.MetricsResponse, error) {
 	ctx, span := tracer.Start(ctx, "Handler.GetMetrics")
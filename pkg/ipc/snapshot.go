@@ -0,0 +1,90 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipc
+
+import (
+	"context"
+
+	"github.com/livekit/psrpc"
+	"github.com/livekit/psrpc/pkg/client"
+	"github.com/livekit/psrpc/pkg/info"
+	"github.com/livekit/psrpc/pkg/rand"
+	"github.com/livekit/psrpc/pkg/server"
+)
+
+// The EgressSnapshot service is served on the message bus by the egress service, using the egress ID as the topic.
+// Requests are passed on to the egress handler.
+const (
+	snapshotService = "EgressSnapshot"
+	getSnapshotRPC  = "GetSnapshot"
+)
+
+type SnapshotClient interface {
+	GetSnapshot(ctx context.Context, egressID string, req *SnapshotRequest, opts ...psrpc.RequestOption) (*SnapshotResponse, error)
+}
+
+type snapshotClient struct {
+	client *client.RPCClient
+}
+
+func NewSnapshotClient(bus psrpc.MessageBus, opts ...psrpc.ClientOption) (SnapshotClient, error) {
+	sd := &info.ServiceDefinition{
+		Name: snapshotService,
+		ID:   rand.NewClientID(),
+	}
+	sd.RegisterMethod(getSnapshotRPC, false, false, true, true)
+
+	rpcClient, err := client.NewRPCClient(sd, bus, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshotClient{
+		client: rpcClient,
+	}, nil
+}
+
+func (c *snapshotClient) GetSnapshot(ctx context.Context, egressID string, req *SnapshotRequest, opts ...psrpc.RequestOption) (*SnapshotResponse, error) {
+	return client.RequestSingle[*SnapshotResponse](ctx, c.client, getSnapshotRPC, []string{egressID}, req, opts...)
+}
+
+type SnapshotServer struct {
+	rpc *server.RPCServer
+}
+
+func NewSnapshotServer(bus psrpc.MessageBus, opts ...psrpc.ServerOption) *SnapshotServer {
+	sd := &info.ServiceDefinition{
+		Name: snapshotService,
+		ID:   rand.NewServerID(),
+	}
+	s := server.NewRPCServer(sd, bus, opts...)
+	sd.RegisterMethod(getSnapshotRPC, false, false, true, true)
+
+	return &SnapshotServer{
+		rpc: s,
+	}
+}
+
+func (s *SnapshotServer) RegisterEgress(egressID string, getSnapshot func(context.Context, *SnapshotRequest) (*SnapshotResponse, error)) error {
+	return server.RegisterHandler(s.rpc, getSnapshotRPC, []string{egressID}, getSnapshot, nil)
+}
+
+func (s *SnapshotServer) DeregisterEgress(egressID string) {
+	s.rpc.DeregisterHandler(getSnapshotRPC, []string{egressID})
+}
+
+func (s *SnapshotServer) Shutdown() {
+	s.rpc.Close(false)
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/psrpc"
+)
+
+func TestSnapshotService(t *testing.T) {
+	bus := psrpc.NewLocalMessageBus()
+
+	s := NewSnapshotServer(bus)
+	defer s.Shutdown()
+	require.NoError(t, s.RegisterEgress("EG_1", func(_ context.Context, req *SnapshotRequest) (*SnapshotResponse, error) {
+		return &SnapshotResponse{Location: req.Filepath, MimeType: "image/png"}, nil
+	}))
+
+	c, err := NewSnapshotClient(bus)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	res, err := c.GetSnapshot(ctx, "EG_1", &SnapshotRequest{Filepath: "moderation/frame.png"})
+	require.NoError(t, err)
+	require.Equal(t, "moderation/frame.png", res.Location)
+
+	// requests are only answered for running egresses
+	s.DeregisterEgress("EG_1")
+	_, err = c.GetSnapshot(ctx, "EG_1", &SnapshotRequest{}, psrpc.WithRequestTimeout(100*time.Millisecond))
+	require.Error(t, err)
+}
@@ -17,6 +17,7 @@ package builder
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/go-gst/go-gst/gst"
 
@@ -27,7 +28,13 @@ import (
 	lksdk "github.com/livekit/server-sdk-go/v2"
 )
 
-const audioMixerLatency = uint64(2e9)
+const (
+	audioMixerLatency = uint64(2e9)
+
+	loudnessMeterName = "loudness"
+
+	speakingLevelInterval = time.Millisecond * 100
+)
 
 type AudioBin struct {
 	bin  *gstreamer.Bin
@@ -127,6 +134,11 @@ func (b *AudioBin) buildWebInput() error {
 	if err = addAudioConverter(b.bin, b.conf); err != nil {
 		return err
 	}
+	if b.conf.AudioProcessing.Enabled() {
+		if err = b.addMixProcessing(); err != nil {
+			return err
+		}
+	}
 	if b.conf.AudioTranscoding {
 		if err = b.addEncoder(); err != nil {
 			return err
@@ -142,12 +154,22 @@ func (b *AudioBin) buildSDKInput() error {
 			return err
 		}
 	}
+	for _, ts := range b.conf.AudioTracks {
+		if err := b.addAudioAppSrcBin(ts); err != nil {
+			return err
+		}
+	}
 	if err := b.addAudioTestSrcBin(); err != nil {
 		return err
 	}
 	if err := b.addMixer(); err != nil {
 		return err
 	}
+	if b.conf.AudioProcessing.Enabled() {
+		if err := b.addMixProcessing(); err != nil {
+			return err
+		}
+	}
 	if b.conf.AudioTranscoding {
 		if err := b.addEncoder(); err != nil {
 			return err
@@ -204,6 +226,34 @@ func (b *AudioBin) addAudioAppSrcBin(ts *config.TrackSource) error {
 		return errors.ErrNotSupported(string(ts.MimeType))
 	}
 
+	if b.conf.AudioProcessing.Enabled() && b.conf.AudioProcessing.PerTrack {
+		processing, err := buildAudioProcessing(b.conf)
+		if err != nil {
+			return err
+		}
+		if err = appSrcBin.AddElements(processing...); err != nil {
+			return err
+		}
+	}
+
+	if b.conf.Speakers != nil {
+		levelName := fmt.Sprintf("%s_level", name)
+		level, err := gst.NewElementWithName("level", levelName)
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = level.SetProperty("interval", uint64(speakingLevelInterval)); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = level.SetProperty("post-messages", true); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = appSrcBin.AddElement(level); err != nil {
+			return err
+		}
+		b.conf.Speakers.AddTrack(levelName, ts.Identity)
+	}
+
 	if err := addAudioConverter(appSrcBin, b.conf); err != nil {
 		return err
 	}
@@ -260,6 +310,158 @@ func (b *AudioBin) addMixer() error {
 	return b.bin.AddElements(audioMixer, mixedCaps)
 }
 
+// addMixProcessing processes the mix unless tracks are processed individually, and measures its loudness
+func (b *AudioBin) addMixProcessing() error {
+	var elements []*gst.Element
+	if !b.conf.AudioProcessing.PerTrack || b.conf.SourceType == types.SourceTypeWeb {
+		processing, err := buildAudioProcessing(b.conf)
+		if err != nil {
+			return err
+		}
+
+		audioConvert, err := gst.NewElement("audioconvert")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+
+		audioResample, err := gst.NewElement("audioresample")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+
+		capsFilter, err := newAudioCapsFilter(b.conf)
+		if err != nil {
+			return err
+		}
+
+		elements = append(processing, audioConvert, audioResample, capsFilter)
+	}
+
+	loudness, err := gst.NewElementWithName("ebur128level", loudnessMeterName)
+	if err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	if err = loudness.SetProperty("post-messages", true); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	elements = append(elements, loudness)
+
+	return b.bin.AddElements(elements...)
+}
+
+// buildAudioProcessing returns the configured filters, which must be followed by a converter
+func buildAudioProcessing(p *config.PipelineConfig) ([]*gst.Element, error) {
+	conf := p.AudioProcessing
+
+	// audiocheblimit and audiodynamic both accept F32
+	elements, err := newProcessingConverter("audio/x-raw,format=F32LE,layout=interleaved")
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.HighPassFrequency > 0 {
+		highPass, err := gst.NewElement("audiocheblimit")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		highPass.SetArg("mode", "high-pass")
+		if err = highPass.SetProperty("cutoff", float32(conf.HighPassFrequency)); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = highPass.SetProperty("poles", 4); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		elements = append(elements, highPass)
+	}
+
+	if conf.Compressor {
+		compressor, err := gst.NewElement("audiodynamic")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		compressor.SetArg("mode", "compressor")
+		compressor.SetArg("characteristics", "soft-knee")
+		if err = compressor.SetProperty("threshold", float32(0.25)); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = compressor.SetProperty("ratio", float32(0.5)); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+
+		limiter, err := gst.NewElement("audiodynamic")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		limiter.SetArg("mode", "compressor")
+		limiter.SetArg("characteristics", "hard-knee")
+		if err = limiter.SetProperty("threshold", float32(0.9)); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = limiter.SetProperty("ratio", float32(0)); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+
+		elements = append(elements, compressor, limiter)
+	}
+
+	if conf.TargetLoudness != 0 {
+		// audioloudnorm only accepts F64 at 192kHz
+		before, err := newProcessingConverter("audio/x-raw,format=F64LE,layout=interleaved,rate=192000")
+		if err != nil {
+			return nil, err
+		}
+
+		loudNorm, err := gst.NewElement("audioloudnorm")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = loudNorm.SetProperty("loudness-target", conf.TargetLoudness); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+
+		// back to the output format and rate
+		after, err := newProcessingConverter("")
+		if err != nil {
+			return nil, err
+		}
+		capsFilter, err := newAudioCapsFilter(p)
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, before...)
+		elements = append(elements, loudNorm)
+		elements = append(elements, after...)
+		elements = append(elements, capsFilter)
+	}
+
+	return elements, nil
+}
+
+// newProcessingConverter returns an audioconvert and audioresample, followed by a capsfilter if caps are given
+func newProcessingConverter(caps string) ([]*gst.Element, error) {
+	audioConvert, err := gst.NewElement("audioconvert")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	audioResample, err := gst.NewElement("audioresample")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if caps == "" {
+		return []*gst.Element{audioConvert, audioResample}, nil
+	}
+
+	capsFilter, err := gst.NewElement("capsfilter")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = capsFilter.SetProperty("caps", gst.NewCapsFromString(caps)); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	return []*gst.Element{audioConvert, audioResample, capsFilter}, nil
+}
+
 func (b *AudioBin) addEncoder() error {
 	switch b.conf.AudioOutCodec {
 	case types.MimeTypeOpus:
@@ -282,6 +484,27 @@ func (b *AudioBin) addEncoder() error {
 		}
 		return b.bin.AddElement(faac)
 
+	case types.MimeTypeFLAC:
+		flacEnc, err := gst.NewElement("flacenc")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		return b.bin.AddElement(flacEnc)
+
+	case types.MimeTypeMP3:
+		lame, err := gst.NewElement("lamemp3enc")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		lame.SetArg("target", "bitrate")
+		if err = lame.SetProperty("bitrate", int(b.conf.AudioBitrate)); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = lame.SetProperty("cbr", true); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		return b.bin.AddElement(lame)
+
 	case types.MimeTypeRawAudio:
 		return nil
 
@@ -317,14 +540,24 @@ func addAudioConverter(b *gstreamer.Bin, p *config.PipelineConfig) error {
 func newAudioCapsFilter(p *config.PipelineConfig) (*gst.Element, error) {
 	var caps *gst.Caps
 	switch p.AudioOutCodec {
-	case types.MimeTypeOpus, types.MimeTypeRawAudio:
+	case types.MimeTypeRawAudio:
+		if p.GetFileConfig() != nil {
+			// wav
+			caps = gst.NewCapsFromString(fmt.Sprintf(
+				"audio/x-raw,format=S16LE,layout=interleaved,rate=%d,channels=%d",
+				p.AudioFrequency, p.AudioChannels,
+			))
+			break
+		}
+		fallthrough
+	case types.MimeTypeOpus:
 		caps = gst.NewCapsFromString(
 			"audio/x-raw,format=S16LE,layout=interleaved,rate=48000,channels=2",
 		)
-	case types.MimeTypeAAC:
+	case types.MimeTypeAAC, types.MimeTypeFLAC, types.MimeTypeMP3:
 		caps = gst.NewCapsFromString(fmt.Sprintf(
-			"audio/x-raw,format=S16LE,layout=interleaved,rate=%d,channels=2",
-			p.AudioFrequency,
+			"audio/x-raw,format=S16LE,layout=interleaved,rate=%d,channels=%d",
+			p.AudioFrequency, p.AudioChannels,
 		))
 	default:
 		return nil, errors.ErrNotSupported(string(p.AudioOutCodec))
@@ -0,0 +1,315 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gst/go-gst/gst"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/gstreamer"
+	"github.com/livekit/egress/pkg/types"
+)
+
+const (
+	compositorLatency = uint64(2e9)
+
+	// speaker layout focus width, as a fraction of the output
+	speakerFocusRatio = 0.8
+	// speaker layout carousel size, further inputs are hidden
+	speakerCarouselMax = 4
+)
+
+type rect struct {
+	x, y, w, h int32
+}
+
+func (b *VideoBin) buildCompositorInput() error {
+	b.identities = make(map[string]string)
+	b.muted = make(map[string]bool)
+
+	// add compositor first so pads can be created
+	if err := b.addCompositor(); err != nil {
+		return err
+	}
+
+	b.bin.SetGetSrcPad(b.getSrcPad)
+	b.bin.SetEOSFunc(func() bool {
+		b.mu.Lock()
+		pad := b.pads[videoTestSrcName]
+		b.mu.Unlock()
+
+		pad.SendEvent(gst.NewEOSEvent())
+		return false
+	})
+
+	if err := b.addVideoTestSrcBin(); err != nil {
+		return err
+	}
+	for _, ts := range b.conf.VideoTracks {
+		if err := b.addCompositorAppSrcBin(ts); err != nil {
+			return err
+		}
+	}
+
+	return b.addDecodedVideoSink()
+}
+
+func (b *VideoBin) addCompositor() error {
+	compositor, err := gst.NewElement("compositor")
+	if err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	compositor.SetArg("background", "black")
+	if err = compositor.SetProperty("latency", compositorLatency); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	if err = compositor.SetProperty("ignore-inactive-pads", true); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+
+	caps, err := b.newVideoCapsFilter(true)
+	if err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+
+	if err = b.bin.AddElements(compositor, caps); err != nil {
+		return err
+	}
+
+	b.compositor = compositor
+	return nil
+}
+
+func (b *VideoBin) addCompositorAppSrcBin(ts *config.TrackSource) error {
+	b.mu.Lock()
+	name := fmt.Sprintf("%s_%d", ts.TrackID, b.nextID)
+	b.nextID++
+	b.mu.Unlock()
+
+	appSrcBin, err := b.buildAppSrcBin(ts, name)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.names[ts.TrackID] = name
+	b.identities[name] = ts.Identity
+	b.inputs = append(b.inputs, name)
+	err = b.createCompositorPadLocked(name)
+	if err == nil {
+		err = b.updateLayoutLocked()
+	}
+	b.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return b.bin.AddSourceBin(appSrcBin)
+}
+
+func (b *VideoBin) createCompositorPad(name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.createCompositorPadLocked(name)
+}
+
+func (b *VideoBin) createCompositorPadLocked(name string) error {
+	pad := b.compositor.GetRequestPad("sink_%u")
+	pad.SetArg("sizing-policy", "keep-aspect-ratio")
+
+	properties := map[string]interface{}{
+		"zorder": uint(1),
+		"alpha":  0.0,
+	}
+	if name == videoTestSrcName {
+		// background
+		properties = map[string]interface{}{
+			"zorder": uint(0),
+			"width":  int(b.conf.Width),
+			"height": int(b.conf.Height),
+		}
+	}
+	for property, value := range properties {
+		if err := pad.SetProperty(property, value); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+	}
+
+	b.pads[name] = pad
+	return nil
+}
+
+func (b *VideoBin) removeCompositorInputLocked(name string) {
+	delete(b.identities, name)
+	delete(b.muted, name)
+	for i, input := range b.inputs {
+		if input == name {
+			b.inputs = append(b.inputs[:i], b.inputs[i+1:]...)
+			break
+		}
+	}
+}
+
+func (b *VideoBin) onActiveSpeakersChanged(identities []string) {
+	if b.bin.GetState() > gstreamer.StateRunning {
+		return
+	}
+
+	// error handling can tear down the bin, which takes the lock
+	if err := b.updateSpeaker(identities); err != nil {
+		b.bin.OnError(err)
+	}
+}
+
+// updateSpeaker switches to the loudest speaker with a visible video track
+func (b *VideoBin) updateSpeaker(identities []string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, identity := range identities {
+		for _, name := range b.inputs {
+			if b.identities[name] == identity && !b.muted[name] {
+				if identity == b.speaker {
+					return nil
+				}
+				b.speaker = identity
+				return b.updateLayoutLocked()
+			}
+		}
+	}
+	return nil
+}
+
+func (b *VideoBin) updateLayoutLocked() error {
+	// visible inputs, with the active speaker first
+	visible := make([]string, 0, len(b.inputs))
+	for _, name := range b.inputs {
+		if b.muted[name] {
+			continue
+		}
+		if b.identities[name] == b.speaker {
+			visible = append([]string{name}, visible...)
+		} else {
+			visible = append(visible, name)
+		}
+	}
+
+	rects := getLayoutRects(b.conf.Layout, b.conf.Width, b.conf.Height, len(visible))
+	shown := make(map[string]rect, len(visible))
+	for i, name := range visible {
+		if rects[i].w > 0 && rects[i].h > 0 {
+			shown[name] = rects[i]
+		}
+	}
+	b.setSlateActive(len(shown) == 0)
+
+	for _, name := range b.inputs {
+		pad := b.pads[name]
+		if pad == nil {
+			continue
+		}
+
+		r, ok := shown[name]
+		if !ok {
+			if err := pad.SetProperty("alpha", 0.0); err != nil {
+				return errors.ErrGstPipelineError(err)
+			}
+			continue
+		}
+
+		for property, value := range map[string]interface{}{
+			"xpos":   int(r.x),
+			"ypos":   int(r.y),
+			"width":  int(r.w),
+			"height": int(r.h),
+			"alpha":  1.0,
+		} {
+			if err := pad.SetProperty(property, value); err != nil {
+				return errors.ErrGstPipelineError(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// getLayoutRects returns the position of each input, with the focused input first
+func getLayoutRects(layout string, width, height int32, count int) []rect {
+	rects := make([]rect, count)
+	if count == 0 {
+		return rects
+	}
+
+	switch layout {
+	case types.LayoutSingleSpeaker:
+		rects[0] = rect{w: width, h: height}
+
+	case types.LayoutSpeaker:
+		if count == 1 {
+			rects[0] = rect{w: width, h: height}
+			break
+		}
+
+		focusWidth := int32(float64(width) * speakerFocusRatio)
+		rects[0] = rect{w: focusWidth, h: height}
+
+		// carousel
+		carouselWidth := width - focusWidth
+		carouselCount := min(count-1, speakerCarouselMax)
+		carouselHeight := height / int32(carouselCount)
+		for i := 1; i <= carouselCount; i++ {
+			rects[i] = rect{
+				x: focusWidth,
+				y: int32(i-1) * carouselHeight,
+				w: carouselWidth,
+				h: carouselHeight,
+			}
+		}
+
+	default:
+		cols := int(math.Ceil(math.Sqrt(float64(count))))
+		rows := (count + cols - 1) / cols
+		w := width / int32(cols)
+		h := height / int32(rows)
+		for i := 0; i < count; i++ {
+			rects[i] = rect{
+				x: int32(i%cols) * w,
+				y: int32(i/cols) * h,
+				w: w,
+				h: h,
+			}
+		}
+	}
+
+	return rects
+}
+
+func newCompositorInputCapsFilter() (*gst.Element, error) {
+	caps, err := gst.NewElement("capsfilter")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = caps.SetProperty("caps", gst.NewCapsFromString(
+		"video/x-raw,format=I420,pixel-aspect-ratio=1/1",
+	)); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	return caps, nil
+}
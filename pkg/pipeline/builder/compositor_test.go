@@ -0,0 +1,70 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/egress/pkg/types"
+)
+
+func TestGetLayoutRects(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		layout   string
+		count    int
+		expected []rect
+	}{
+		{name: "empty", layout: types.LayoutGrid, count: 0, expected: []rect{}},
+		{name: "grid single", layout: types.LayoutGrid, count: 1, expected: []rect{
+			{w: 1280, h: 720},
+		}},
+		{name: "grid", layout: types.LayoutGrid, count: 5, expected: []rect{
+			{x: 0, y: 0, w: 426, h: 360},
+			{x: 426, y: 0, w: 426, h: 360},
+			{x: 852, y: 0, w: 426, h: 360},
+			{x: 0, y: 360, w: 426, h: 360},
+			{x: 426, y: 360, w: 426, h: 360},
+		}},
+		{name: "speaker alone", layout: types.LayoutSpeaker, count: 1, expected: []rect{
+			{w: 1280, h: 720},
+		}},
+		{name: "speaker", layout: types.LayoutSpeaker, count: 3, expected: []rect{
+			{w: 1024, h: 720},
+			{x: 1024, y: 0, w: 256, h: 360},
+			{x: 1024, y: 360, w: 256, h: 360},
+		}},
+		{name: "speaker carousel capped", layout: types.LayoutSpeaker, count: 7, expected: []rect{
+			{w: 1024, h: 720},
+			{x: 1024, y: 0, w: 256, h: 180},
+			{x: 1024, y: 180, w: 256, h: 180},
+			{x: 1024, y: 360, w: 256, h: 180},
+			{x: 1024, y: 540, w: 256, h: 180},
+			{},
+			{},
+		}},
+		{name: "single speaker", layout: types.LayoutSingleSpeaker, count: 3, expected: []rect{
+			{w: 1280, h: 720},
+			{},
+			{},
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, getLayoutRects(test.layout, 1280, 720, test.count))
+		})
+	}
+}
@@ -29,7 +29,14 @@ func BuildFileBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) (*gstr
 
 	var mux *gst.Element
 	var err error
+	requestPads := true
 	switch o.OutputType {
+	case types.OutputTypeWAV:
+		mux, err = gst.NewElement("wavenc")
+		requestPads = false
+	case types.OutputTypeFLAC, types.OutputTypeMP3:
+		// encoded streams are written directly
+		requestPads = false
 	case types.OutputTypeOGG:
 		mux, err = gst.NewElement("oggmux")
 	case types.OutputTypeIVF:
@@ -38,6 +45,8 @@ func BuildFileBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) (*gstr
 		mux, err = gst.NewElement("mp4mux")
 	case types.OutputTypeWebM:
 		mux, err = gst.NewElement("webmmux")
+	case types.OutputTypeMKV:
+		mux, err = gst.NewElement("matroskamux")
 	default:
 		err = errors.ErrInvalidInput("output type")
 	}
@@ -55,15 +64,22 @@ func BuildFileBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) (*gstr
 	if err = sink.SetProperty("sync", false); err != nil {
 		return nil, errors.ErrGstPipelineError(err)
 	}
-	if err = b.AddElements(mux, sink); err != nil {
+	if mux != nil {
+		if err = b.AddElement(mux); err != nil {
+			return nil, err
+		}
+	}
+	if err = b.AddElement(sink); err != nil {
 		return nil, err
 	}
 
-	b.SetGetSrcPad(func(name string) *gst.Pad {
-		var padName = name + "_%u"
+	if requestPads {
+		b.SetGetSrcPad(func(name string) *gst.Pad {
+			var padName = name + "_%u"
 
-		return mux.GetRequestPad(padName)
-	})
+			return mux.GetRequestPad(padName)
+		})
+	}
 
 	return b, nil
 }
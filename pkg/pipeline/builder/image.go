@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/go-gst/go-gst/gst"
+	"go.uber.org/atomic"
 
 	"github.com/livekit/egress/pkg/config"
 	"github.com/livekit/egress/pkg/errors"
@@ -89,6 +90,17 @@ func BuildImageBin(c *config.ImageConfig, pipeline *gstreamer.Pipeline, p *confi
 		return nil, errors.ErrGstPipelineError(err)
 	}
 
+	if p.Overlays.EnabledFor(types.EgressTypeImages) {
+		// drawn before scaling, so positions match the encoded outputs
+		overlays, err := buildOverlays(p)
+		if err != nil {
+			return nil, err
+		}
+		if err = b.AddElements(overlays...); err != nil {
+			return nil, err
+		}
+	}
+
 	videoScale, err := gst.NewElement("videoscale")
 	if err != nil {
 		return nil, errors.ErrGstPipelineError(err)
@@ -101,9 +113,21 @@ func BuildImageBin(c *config.ImageConfig, pipeline *gstreamer.Pipeline, p *confi
 	if err != nil {
 		return nil, errors.ErrGstPipelineError(err)
 	}
-	err = caps.SetProperty("caps", gst.NewCapsFromString(fmt.Sprintf(
-		"video/x-raw,framerate=1/%d,format=I420,width=%d,height=%d,colorimetry=bt709,chroma-site=mpeg2,pixel-aspect-ratio=1/1",
-		c.CaptureInterval, c.Width, c.Height)))
+	if c.Preview != nil {
+		// sample frames evenly across the preview window, keeping the aspect ratio
+		err = caps.SetProperty("caps", gst.NewCapsFromString(fmt.Sprintf(
+			"video/x-raw,framerate=%d/%d,format=I420,width=%d,colorimetry=bt709,chroma-site=mpeg2,pixel-aspect-ratio=1/1",
+			c.Preview.GetPreviewFrameCount(), int(c.Preview.Window.Seconds()), c.Width)))
+	} else if c.SceneCapture != nil {
+		// compare frames at the min interval, the scene detector drops unchanged frames
+		err = caps.SetProperty("caps", gst.NewCapsFromString(fmt.Sprintf(
+			"video/x-raw,framerate=1000/%d,format=I420,width=%d,height=%d,colorimetry=bt709,chroma-site=mpeg2,pixel-aspect-ratio=1/1",
+			c.SceneCapture.MinInterval.Milliseconds(), c.Width, c.Height)))
+	} else {
+		err = caps.SetProperty("caps", gst.NewCapsFromString(fmt.Sprintf(
+			"video/x-raw,framerate=1/%d,format=I420,width=%d,height=%d,colorimetry=bt709,chroma-site=mpeg2,pixel-aspect-ratio=1/1",
+			c.CaptureInterval, c.Width, c.Height)))
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -111,18 +135,29 @@ func BuildImageBin(c *config.ImageConfig, pipeline *gstreamer.Pipeline, p *confi
 		return nil, errors.ErrGstPipelineError(err)
 	}
 
+	var enc *gst.Element
 	switch c.ImageOutCodec {
 	case types.MimeTypeJPEG:
-		enc, err := gst.NewElement("jpegenc")
-		if err != nil {
-			return nil, errors.ErrGstPipelineError(err)
-		}
-		if err := b.AddElements(enc); err != nil {
-			return nil, errors.ErrGstPipelineError(err)
-		}
+		enc, err = gst.NewElement("jpegenc")
+	case types.MimeTypePNG:
+		enc, err = gst.NewElement("pngenc")
+	case types.MimeTypeWebP:
+		enc, err = gst.NewElement("webpenc")
 	default:
 		return nil, errors.ErrNoCompatibleCodec
 	}
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = b.AddElements(enc); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if c.SceneCapture != nil {
+		newSceneDetector(c).addProbe(enc.GetStaticPad("sink"))
+	}
+	if c.Preview != nil {
+		addPreviewProbes(queue, enc, c.Preview.GetPreviewFrameCount())
+	}
 
 	sink, err := gst.NewElementWithName("multifilesink", fmt.Sprintf("multifilesink_%s", c.Id))
 	if err != nil {
@@ -134,7 +169,7 @@ func BuildImageBin(c *config.ImageConfig, pipeline *gstreamer.Pipeline, p *confi
 	}
 
 	// File will be renamed if the TS prefix is configured
-	location := fmt.Sprintf("%s_%%05d%s", path.Join(c.LocalDir, c.ImagePrefix), types.FileExtensionForOutputType[c.OutputType])
+	location := fmt.Sprintf("%s_%%05d%s", path.Join(c.LocalDir, c.ImagePrefix), c.ImageExtension)
 
 	err = sink.SetProperty("location", location)
 	if err != nil {
@@ -146,3 +181,24 @@ func BuildImageBin(c *config.ImageConfig, pipeline *gstreamer.Pipeline, p *confi
 
 	return b, nil
 }
+
+// addPreviewProbes stops the branch once the preview frames are encoded.
+// The rest of the branch is sent EOS, and later frames are dropped as they enter the bin
+func addPreviewProbes(queue, enc *gst.Element, frames int32) {
+	var encoded atomic.Int32
+	enc.GetStaticPad("src").AddProbe(gst.PadProbeTypeBuffer, func(_ *gst.Pad, _ *gst.PadProbeInfo) gst.PadProbeReturn {
+		encoded.Inc()
+		return gst.PadProbeOK
+	})
+
+	var eos atomic.Bool
+	queue.GetStaticPad("src").AddProbe(gst.PadProbeTypeBuffer, func(pad *gst.Pad, _ *gst.PadProbeInfo) gst.PadProbeReturn {
+		if encoded.Load() < frames {
+			return gst.PadProbeOK
+		}
+		if eos.CompareAndSwap(false, true) {
+			pad.PushEvent(gst.NewEOSEvent())
+		}
+		return gst.PadProbeDrop
+	})
+}
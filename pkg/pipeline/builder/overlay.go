@@ -0,0 +1,125 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"strings"
+
+	"github.com/go-gst/go-gst/gst"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/errors"
+)
+
+const defaultClockFormat = "%H:%M:%S"
+
+// buildOverlays returns the requested overlays, which operate on raw video
+func buildOverlays(p *config.PipelineConfig) ([]*gst.Element, error) {
+	conf := p.Overlays
+	var elements []*gst.Element
+
+	for _, image := range conf.Images {
+		overlay, err := gst.NewElement("gdkpixbufoverlay")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+
+		opacity := image.Opacity
+		if opacity == 0 {
+			opacity = 1
+		}
+		for property, value := range map[string]interface{}{
+			"location":       image.Filepath,
+			"offset-x":       int(image.X),
+			"offset-y":       int(image.Y),
+			"overlay-width":  int(image.Width),
+			"overlay-height": int(image.Height),
+			"alpha":          opacity,
+		} {
+			if err = overlay.SetProperty(property, value); err != nil {
+				return nil, errors.ErrGstPipelineError(err)
+			}
+		}
+		elements = append(elements, overlay)
+	}
+
+	for _, text := range conf.Text {
+		overlay, err := newTextOverlay("textoverlay", text)
+		if err != nil {
+			return nil, err
+		}
+		if err = overlay.SetProperty("text", replaceTemplates(p, text.Text)); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		elements = append(elements, overlay)
+	}
+
+	if conf.Clock != nil {
+		overlay, err := newTextOverlay("clockoverlay", conf.Clock)
+		if err != nil {
+			return nil, err
+		}
+		format := conf.Clock.Text
+		if format == "" {
+			format = defaultClockFormat
+		}
+		if err = overlay.SetProperty("time-format", format); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		elements = append(elements, overlay)
+	}
+
+	return elements, nil
+}
+
+func replaceTemplates(p *config.PipelineConfig, text string) string {
+	replacements := map[string]string{
+		"{room_name}":          p.Info.RoomName,
+		"{room_id}":            p.Info.RoomId,
+		"{publisher_identity}": p.Identity,
+		"{egress_id}":          p.Info.EgressId,
+	}
+	for template, value := range replacements {
+		text = strings.ReplaceAll(text, template, value)
+	}
+	return text
+}
+
+func newTextOverlay(factory string, conf *config.TextOverlay) (*gst.Element, error) {
+	overlay, err := gst.NewElement(factory)
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	halign, valign := conf.HAlign, conf.VAlign
+	if halign == "" {
+		halign = "left"
+	}
+	if valign == "" {
+		valign = "top"
+	}
+	overlay.SetArg("halignment", halign)
+	overlay.SetArg("valignment", valign)
+	if err = overlay.SetProperty("shaded-background", true); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if conf.Font != "" {
+		if err = overlay.SetProperty("font-desc", conf.Font); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+	}
+
+	return overlay, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gst/go-gst/gst"
+
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/protocol/logger"
+)
+
+// buildScaler scales video of the given input size (0 if unknown) to the output size. The output caps never change,
+// so tracks can switch resolution mid-stream (simulcast layers) without renegotiating downstream
+func (b *VideoBin) buildScaler(width, height int) ([]*gst.Element, error) {
+	videoScale, err := gst.NewElement("videoscale")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	conf := b.conf.VideoScaling
+	switch {
+	case conf.Mode == "stretch":
+		if err = videoScale.SetProperty("add-borders", false); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		return []*gst.Element{videoScale}, nil
+
+	case conf.Mode == "fill":
+		// cropped to the output aspect ratio, whatever the input size
+		aspectRatioCrop, err := gst.NewElement("aspectratiocrop")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		aspectRatioCrop.SetArg("aspect-ratio", fmt.Sprintf("%d/%d", b.conf.Width, b.conf.Height))
+		return []*gst.Element{aspectRatioCrop, videoScale}, nil
+
+	case conf.Background != "" && conf.Background != "black" && width > 0 && height > 0:
+		// scaled to fit, then padded by videobox. Layers keep the published aspect ratio
+
+	default:
+		// videoscale adds black borders
+		return []*gst.Element{videoScale}, nil
+	}
+
+	w, h := getScaledSize(width, height, int(b.conf.Width), int(b.conf.Height))
+	logger.Debugw("scaling video", "input", fmt.Sprintf("%dx%d", width, height), "scaled", fmt.Sprintf("%dx%d", w, h))
+
+	caps, err := gst.NewElement("capsfilter")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = caps.SetProperty("caps", gst.NewCapsFromString(fmt.Sprintf(
+		"video/x-raw,width=%d,height=%d,pixel-aspect-ratio=1/1", w, h,
+	))); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	videoBox, err := gst.NewElement("videobox")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = videoBox.SetProperty("autocrop", true); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	videoBox.SetArg("fill", conf.Background)
+
+	return []*gst.Element{videoScale, caps, videoBox}, nil
+}
+
+func getCapsSize(caps *gst.Caps) (int, int, bool) {
+	if caps == nil || caps.GetSize() == 0 {
+		return 0, 0, false
+	}
+
+	s := caps.GetStructureAt(0)
+	width, err := s.GetValue("width")
+	if err != nil {
+		return 0, 0, false
+	}
+	height, err := s.GetValue("height")
+	if err != nil {
+		return 0, 0, false
+	}
+
+	w, wOK := width.(int)
+	h, hOK := height.(int)
+	return w, h, wOK && hOK && w > 0 && h > 0
+}
+
+// getScaledSize keeps the aspect ratio, fitting inside the output
+func getScaledSize(width, height, outWidth, outHeight int) (int, int) {
+	scale := math.Min(float64(outWidth)/float64(width), float64(outHeight)/float64(height))
+
+	// I420 needs even dimensions
+	w := int(math.Round(float64(width)*scale/2)) * 2
+	h := int(math.Round(float64(height)*scale/2)) * 2
+	return min(max(w, 2), outWidth), min(max(h, 2), outHeight)
+}
@@ -0,0 +1,45 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetScaledSize(t *testing.T) {
+	for _, test := range []struct {
+		name                string
+		width, height       int
+		outWidth, outHeight int
+		expectedW           int
+		expectedH           int
+	}{
+		{name: "same aspect", width: 640, height: 360, outWidth: 1280, outHeight: 720, expectedW: 1280, expectedH: 720},
+		{name: "pillarbox", width: 720, height: 1280, outWidth: 1280, outHeight: 720, expectedW: 406, expectedH: 720},
+		{name: "letterbox", width: 1920, height: 800, outWidth: 1280, outHeight: 720, expectedW: 1280, expectedH: 534},
+		{name: "downscale", width: 3840, height: 2160, outWidth: 640, outHeight: 480, expectedW: 640, expectedH: 360},
+		{name: "rounding", width: 1000, height: 562, outWidth: 1280, outHeight: 720, expectedW: 1280, expectedH: 720},
+		{name: "sliver", width: 4000, height: 2, outWidth: 1280, outHeight: 720, expectedW: 1280, expectedH: 2},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w, h := getScaledSize(test.width, test.height, test.outWidth, test.outHeight)
+			require.Equal(t, test.expectedW, w)
+			require.Equal(t, test.expectedH, h)
+			require.Zero(t, w%2+h%2)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"time"
+
+	"github.com/go-gst/go-gst/gst"
+
+	"github.com/livekit/egress/pkg/config"
+)
+
+const (
+	sceneGridWidth  = 80
+	sceneGridHeight = 45
+	// luma changes below this are treated as compression noise
+	sceneNoiseLevel = 32
+)
+
+// sceneDetector compares a grid of luma samples against the last captured frame
+type sceneDetector struct {
+	conf   *config.ImageSceneCaptureConfig
+	width  int
+	height int
+
+	last     []byte
+	lastTime time.Duration
+}
+
+func newSceneDetector(c *config.ImageConfig) *sceneDetector {
+	return &sceneDetector{
+		conf:   c.SceneCapture,
+		width:  int(c.Width),
+		height: int(c.Height),
+	}
+}
+
+// addProbe drops frames which are too similar to the last captured frame
+func (d *sceneDetector) addProbe(pad *gst.Pad) {
+	pad.AddProbe(gst.PadProbeTypeBuffer, func(_ *gst.Pad, info *gst.PadProbeInfo) gst.PadProbeReturn {
+		buffer := info.GetBuffer()
+		if buffer == nil {
+			return gst.PadProbeOK
+		}
+
+		mapInfo := buffer.Map(gst.MapRead)
+		if mapInfo == nil {
+			return gst.PadProbeOK
+		}
+		samples := d.sample(mapInfo.Bytes())
+		buffer.Unmap()
+
+		pts := time.Duration(buffer.PresentationTimestamp())
+		if d.shouldCapture(samples, pts) {
+			return gst.PadProbeOK
+		}
+		return gst.PadProbeDrop
+	})
+}
+
+// sample reads the luma plane of an I420 frame
+func (d *sceneDetector) sample(frame []byte) []byte {
+	stride := (d.width + 3) &^ 3
+	if d.width == 0 || d.height == 0 || len(frame) < stride*d.height {
+		return nil
+	}
+
+	samples := make([]byte, 0, sceneGridWidth*sceneGridHeight)
+	for gy := 0; gy < sceneGridHeight; gy++ {
+		y := (gy*d.height + d.height/2) / sceneGridHeight
+		for gx := 0; gx < sceneGridWidth; gx++ {
+			x := (gx*d.width + d.width/2) / sceneGridWidth
+			samples = append(samples, frame[y*stride+x])
+		}
+	}
+	return samples
+}
+
+func (d *sceneDetector) shouldCapture(samples []byte, pts time.Duration) bool {
+	switch {
+	case samples == nil:
+		// unexpected frame layout, fall back to interval capture
+	case d.last == nil:
+	case d.conf.MaxInterval > 0 && pts-d.lastTime >= d.conf.MaxInterval:
+	case d.changed(samples) < d.conf.Threshold:
+		return false
+	}
+
+	d.last = samples
+	d.lastTime = pts
+	return true
+}
+
+// changed returns the fraction of samples which differ from the last captured frame
+func (d *sceneDetector) changed(samples []byte) float64 {
+	var count int
+	for i, v := range samples {
+		diff := int(v) - int(d.last[i])
+		if diff > sceneNoiseLevel || diff < -sceneNoiseLevel {
+			count++
+		}
+	}
+	return float64(count) / float64(len(samples))
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/egress/pkg/config"
+)
+
+func TestSceneDetector(t *testing.T) {
+	const width, height = 160, 90
+	frame := func(luma byte, changedRows int) []byte {
+		// I420: a full resolution luma plane followed by the chroma planes
+		b := make([]byte, width*height*3/2)
+		for i := 0; i < width*height; i++ {
+			b[i] = luma
+			if i/width < changedRows {
+				b[i] = luma + 100
+			}
+		}
+		return b
+	}
+
+	d := &sceneDetector{
+		conf: &config.ImageSceneCaptureConfig{
+			Threshold:   0.1,
+			MinInterval: time.Second,
+			MaxInterval: 10 * time.Second,
+		},
+		width:  width,
+		height: height,
+	}
+
+	// unexpected frame size
+	require.Nil(t, d.sample(frame(0, 0)[:width*height-1]))
+
+	base := d.sample(frame(0, 0))
+	require.Len(t, base, sceneGridWidth*sceneGridHeight)
+	require.True(t, d.shouldCapture(base, 0), "first frame")
+	require.False(t, d.shouldCapture(d.sample(frame(10, 0)), time.Second), "noise")
+
+	// 5 of 90 rows is below the threshold, 18 of 90 is above it
+	small := d.sample(frame(0, 5))
+	require.InDelta(t, 5.0/90, d.changed(small), 0.02)
+	require.False(t, d.shouldCapture(small, 2*time.Second))
+	large := d.sample(frame(0, 18))
+	require.InDelta(t, 0.2, d.changed(large), 0.02)
+	require.True(t, d.shouldCapture(large, 3*time.Second))
+
+	// compared against the last captured frame
+	require.False(t, d.shouldCapture(d.sample(frame(0, 18)), 4*time.Second))
+	require.True(t, d.shouldCapture(base, 5*time.Second))
+
+	// captured without changes once max_interval has passed
+	require.False(t, d.shouldCapture(base, 14*time.Second))
+	require.True(t, d.shouldCapture(base, 15*time.Second), "max interval")
+
+	// unexpected frame layouts fall back to interval capture
+	require.True(t, d.shouldCapture(nil, 16*time.Second))
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/livekit/egress/pkg/config"
 	"github.com/livekit/egress/pkg/errors"
 	"github.com/livekit/egress/pkg/gstreamer"
+	"github.com/livekit/egress/pkg/types"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 )
@@ -36,15 +37,22 @@ func BuildSegmentBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) (*g
 	b := pipeline.NewBin("segment")
 	o := p.GetSegmentConfig()
 
-	var h264parse *gst.Element
+	outputType := p.GetSegmentOutputType()
+	ext := types.FileExtensionForOutputType[outputType]
+
+	var videoParse *gst.Element
 	var err error
 	if p.VideoEnabled {
-		h264parse, err = gst.NewElement("h264parse")
+		if p.VideoOutCodec == types.MimeTypeH265 {
+			videoParse, err = gst.NewElement("h265parse")
+		} else {
+			videoParse, err = gst.NewElement("h264parse")
+		}
 		if err != nil {
 			return nil, err
 		}
 
-		if err = b.AddElements(h264parse); err != nil {
+		if err = b.AddElements(videoParse); err != nil {
 			return nil, errors.ErrGstPipelineError(err)
 		}
 	}
@@ -59,7 +67,22 @@ func BuildSegmentBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) (*g
 	if err = sink.SetProperty("send-keyframe-requests", true); err != nil {
 		return nil, errors.ErrGstPipelineError(err)
 	}
-	if err = sink.SetProperty("muxer-factory", "mpegtsmux"); err != nil {
+	if outputType == types.OutputTypeM4S {
+		// each segment starts with its own init section, followed by a single fragment
+		mux, err := gst.NewElement("mp4mux")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = mux.SetProperty("fragment-duration", uint(o.SegmentDuration*1000)); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = mux.SetProperty("streamable", true); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = sink.SetProperty("muxer", mux); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+	} else if err = sink.SetProperty("muxer-factory", "mpegtsmux"); err != nil {
 		return nil, errors.ErrGstPipelineError(err)
 	}
 
@@ -89,9 +112,9 @@ func BuildSegmentBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) (*g
 		switch o.SegmentSuffix {
 		case livekit.SegmentedFileSuffix_TIMESTAMP:
 			ts := startDate.Add(pts)
-			segmentName = fmt.Sprintf("%s_%s%03d.ts", o.SegmentPrefix, ts.Format("20060102150405"), ts.UnixMilli()%1000)
+			segmentName = fmt.Sprintf("%s_%s%03d%s", o.SegmentPrefix, ts.Format("20060102150405"), ts.UnixMilli()%1000, ext)
 		default:
-			segmentName = fmt.Sprintf("%s_%05d.ts", o.SegmentPrefix, fragmentId)
+			segmentName = fmt.Sprintf("%s_%05d%s", o.SegmentPrefix, fragmentId, ext)
 		}
 		return path.Join(o.LocalDir, segmentName)
 	})
@@ -106,8 +129,8 @@ func BuildSegmentBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) (*g
 	b.SetGetSrcPad(func(name string) *gst.Pad {
 		if name == "audio" {
 			return sink.GetRequestPad("audio_%u")
-		} else if h264parse != nil {
-			return h264parse.GetStaticPad("sink")
+		} else if videoParse != nil {
+			return videoParse.GetStaticPad("sink")
 		} else {
 			// Should never happen
 			return nil
@@ -0,0 +1,298 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frostbyte73/core"
+	"github.com/go-gst/go-gst/gst"
+	"github.com/go-gst/go-gst/gst/app"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	slateStartTimeout = time.Second * 5
+	slateRetryDelay   = time.Second * 5
+)
+
+// buildSlateSource replaces the black test source with the configured slate
+func (b *VideoBin) buildSlateSource() ([]*gst.Element, error) {
+	conf := b.conf.Slate
+	switch {
+	case conf.Image != "":
+		return b.buildSlateImage(conf.Image)
+	case conf.Video != "":
+		return b.buildSlateVideo(conf.Video)
+	default:
+		return b.buildSlateText(conf)
+	}
+}
+
+func (b *VideoBin) buildSlateImage(location string) ([]*gst.Element, error) {
+	fileSrc, err := gst.NewElement("filesrc")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = fileSrc.SetProperty("location", location); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	var dec *gst.Element
+	if strings.ToLower(filepath.Ext(location)) == ".png" {
+		dec, err = gst.NewElement("pngdec")
+	} else {
+		dec, err = gst.NewElement("jpegdec")
+	}
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	videoConvert, err := gst.NewElement("videoconvert")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	videoScale, err := gst.NewElement("videoscale")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	imageFreeze, err := gst.NewElement("imagefreeze")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = imageFreeze.SetProperty("is-live", true); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	return []*gst.Element{fileSrc, dec, videoConvert, videoScale, imageFreeze}, nil
+}
+
+func (b *VideoBin) buildSlateText(conf *config.SlateConfig) ([]*gst.Element, error) {
+	videoTestSrc, err := gst.NewElement("videotestsrc")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = videoTestSrc.SetProperty("is-live", true); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	videoTestSrc.SetArg("pattern", "solid-color")
+	if err = videoTestSrc.SetProperty("foreground-color", conf.GetBackgroundColor()); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	text := *conf.Text
+	if text.HAlign == "" {
+		text.HAlign = "center"
+	}
+	if text.VAlign == "" {
+		text.VAlign = "center"
+	}
+	overlay, err := newTextOverlay("textoverlay", &text)
+	if err != nil {
+		return nil, err
+	}
+	if err = overlay.SetProperty("shaded-background", false); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = overlay.SetProperty("text", replaceTemplates(b.conf, text.Text)); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	return []*gst.Element{videoTestSrc, overlay}, nil
+}
+
+// buildSlateVideo loops the file in a separate pipeline, feeding decoded frames into a live appsrc
+func (b *VideoBin) buildSlateVideo(location string) ([]*gst.Element, error) {
+	location, err := filepath.Abs(location)
+	if err != nil {
+		return nil, err
+	}
+
+	rawCaps := fmt.Sprintf(
+		"video/x-raw,format=I420,width=%d,height=%d,framerate=%d/1,pixel-aspect-ratio=1/1",
+		b.conf.Width, b.conf.Height, b.conf.Framerate,
+	)
+
+	src, err := app.NewAppSrc()
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	src.SetArg("format", "time")
+	src.SetArg("leaky-type", "downstream")
+	for property, value := range map[string]interface{}{
+		"is-live":      true,
+		"do-timestamp": true,
+		"max-buffers":  uint64(2),
+	} {
+		if err = src.SetProperty(property, value); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+	}
+	src.SetCaps(gst.NewCapsFromString(rawCaps))
+
+	player, err := gst.NewPipelineFromString(fmt.Sprintf(
+		`uridecodebin uri="file://%s" caps=video/x-raw ! videoconvert ! videoscale ! videorate ! %s ! appsink name=slate_sink sync=true`,
+		location, rawCaps,
+	))
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	sinkElement, err := player.GetElementByName("slate_sink")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	app.SinkFromElement(sinkElement).SetCallbacks(&app.SinkCallbacks{
+		NewSampleFunc: func(sink *app.Sink) gst.FlowReturn {
+			sample := sink.PullSample()
+			if sample == nil {
+				return gst.FlowEOS
+			}
+			// restamped with the running time of the egress pipeline
+			buffer := sample.GetBuffer().Copy()
+			buffer.SetPresentationTimestamp(gst.ClockTimeNone)
+			src.PushBuffer(buffer)
+			return gst.FlowOK
+		},
+	})
+
+	// prerolled, so a missing or unreadable file fails the build, and played only while the slate is shown
+	if err = loadSlatePlayer(player); err != nil {
+		return nil, err
+	}
+
+	b.slate = &slatePlayer{pipeline: player}
+	go b.slate.loop()
+	b.bin.AddOnStop(b.slate.stop)
+
+	return []*gst.Element{src.Element}, nil
+}
+
+// setSlateActive plays the slate video while it is shown, and pauses it otherwise
+func (b *VideoBin) setSlateActive(active bool) {
+	if b.slate != nil {
+		b.slate.setActive(active)
+	}
+}
+
+type slatePlayer struct {
+	pipeline *gst.Pipeline
+
+	mu      sync.Mutex
+	active  bool
+	stopped core.Fuse
+}
+
+func loadSlatePlayer(player *gst.Pipeline) error {
+	if err := player.SetState(gst.StatePaused); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	if res, _ := player.GetState(gst.StatePaused, gst.ClockTime(uint64(slateStartTimeout))); res == gst.StateChangeFailure {
+		return errors.ErrGstPipelineError(errors.New("failed to load slate video"))
+	}
+
+	// segment seeks post segment-done instead of eos, so the video can be restarted without flushing
+	if !player.SeekSimple(0, gst.FormatTime, gst.SeekFlagFlush|gst.SeekFlagSegment) {
+		return errors.ErrGstPipelineError(errors.New("failed to seek slate video"))
+	}
+	return nil
+}
+
+func (p *slatePlayer) setActive(active bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.active == active || p.stopped.IsBroken() {
+		return
+	}
+	p.active = active
+
+	state := gst.StatePaused
+	if active {
+		state = gst.StatePlaying
+	}
+	if err := p.pipeline.SetState(state); err != nil {
+		logger.Warnw("failed to update slate video", err, "state", state)
+	}
+}
+
+func (p *slatePlayer) loop() {
+	bus := p.pipeline.GetPipelineBus()
+	for !p.stopped.IsBroken() {
+		msg := bus.TimedPopFiltered(gst.ClockTime(uint64(time.Second)), gst.MessageSegmentDone|gst.MessageError|gst.MessageEOS)
+		if msg == nil {
+			continue
+		}
+
+		switch msg.Type() {
+		case gst.MessageSegmentDone:
+			p.pipeline.SeekSimple(0, gst.FormatTime, gst.SeekFlagSegment)
+		case gst.MessageEOS:
+			// some demuxers post eos regardless of the segment seek
+			p.mu.Lock()
+			if !p.pipeline.SeekSimple(0, gst.FormatTime, gst.SeekFlagFlush|gst.SeekFlagSegment) {
+				logger.Warnw("failed to restart slate video", nil)
+			}
+			p.mu.Unlock()
+		case gst.MessageError:
+			logger.Warnw("slate video failed", msg.ParseError())
+			for err := p.reload(); err != nil; err = p.reload() {
+				logger.Warnw("failed to reload slate video", err)
+				select {
+				case <-p.stopped.Watch():
+					return
+				case <-time.After(slateRetryDelay):
+				}
+			}
+		}
+	}
+}
+
+// reload restarts the player from the beginning, resuming playback if the slate is shown
+func (p *slatePlayer) reload() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopped.IsBroken() {
+		return nil
+	}
+	if err := p.pipeline.SetState(gst.StateNull); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	if err := loadSlatePlayer(p.pipeline); err != nil {
+		return err
+	}
+	if p.active {
+		if err := p.pipeline.SetState(gst.StatePlaying); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+	}
+	return nil
+}
+
+func (p *slatePlayer) stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stopped.Break()
+	return p.pipeline.SetState(gst.StateNull)
+}
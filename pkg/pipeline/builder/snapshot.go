@@ -0,0 +1,121 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-gst/go-gst/gst"
+	"github.com/go-gst/go-gst/gst/app"
+
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/types"
+)
+
+const snapshotEncodeTimeout = time.Second * 5
+
+type snapshotFrame struct {
+	buffer *gst.Buffer
+	caps   *gst.Caps
+}
+
+// GetSnapshot grabs the next raw frame and encodes it, without changing the running pipeline
+func (b *VideoBin) GetSnapshot(ctx context.Context, mimeType types.MimeType, width, height int32) ([]byte, error) {
+	if b.rawVideoTee == nil {
+		return nil, errors.ErrNotSupported("snapshots without decoded video")
+	}
+
+	frames := make(chan *snapshotFrame, 1)
+	pad := b.rawVideoTee.GetStaticPad("sink")
+	pad.AddProbe(gst.PadProbeTypeBuffer, func(pad *gst.Pad, info *gst.PadProbeInfo) gst.PadProbeReturn {
+		buffer := info.GetBuffer()
+		if buffer == nil {
+			return gst.PadProbeOK
+		}
+		frames <- &snapshotFrame{
+			buffer: buffer.Copy(),
+			caps:   pad.GetCurrentCaps(),
+		}
+		return gst.PadProbeRemove
+	})
+
+	select {
+	case frame := <-frames:
+		return encodeSnapshot(frame, mimeType, width, height)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func encodeSnapshot(frame *snapshotFrame, mimeType types.MimeType, width, height int32) ([]byte, error) {
+	var enc string
+	switch mimeType {
+	case types.MimeTypeJPEG:
+		enc = "jpegenc"
+	case types.MimeTypePNG:
+		enc = "pngenc"
+	default:
+		return nil, errors.ErrNoCompatibleCodec
+	}
+
+	caps := "video/x-raw,pixel-aspect-ratio=1/1"
+	if width > 0 {
+		caps += fmt.Sprintf(",width=%d", width)
+	}
+	if height > 0 {
+		caps += fmt.Sprintf(",height=%d", height)
+	}
+
+	pipeline, err := gst.NewPipelineFromString(fmt.Sprintf(
+		"appsrc name=snapshot_src format=time ! videoconvert ! videoscale ! %s ! %s ! appsink name=snapshot_sink sync=false",
+		caps, enc,
+	))
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	defer func() {
+		_ = pipeline.SetState(gst.StateNull)
+	}()
+
+	srcElement, err := pipeline.GetElementByName("snapshot_src")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	sinkElement, err := pipeline.GetElementByName("snapshot_sink")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	src := app.SrcFromElement(srcElement)
+	sink := app.SinkFromElement(sinkElement)
+
+	src.SetCaps(frame.caps)
+	if err = pipeline.SetState(gst.StatePlaying); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	frame.buffer.SetPresentationTimestamp(0)
+	if flow := src.PushBuffer(frame.buffer); flow != gst.FlowOK {
+		return nil, errors.ErrGstPipelineError(errors.New(flow.String()))
+	}
+	src.EndStream()
+
+	sample := sink.TryPullSample(gst.ClockTime(uint64(snapshotEncodeTimeout)))
+	if sample == nil {
+		return nil, errors.ErrGstPipelineError(errors.New("snapshot encoding timed out"))
+	}
+	return sample.GetBuffer().Bytes(), nil
+}
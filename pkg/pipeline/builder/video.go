@@ -34,6 +34,7 @@ import (
 
 const (
 	videoTestSrcName = "video_test_src"
+	webSrcName       = "web_src"
 )
 
 type VideoBin struct {
@@ -48,9 +49,25 @@ type VideoBin struct {
 	names       map[string]string
 	selector    *gst.Element
 	rawVideoTee *gst.Element
+	overlayTee  *gst.Element // encoded video with overlays, when other outputs have none
+	slate       *slatePlayer // slate video, when configured
+
+	// room composite
+	compositor *gst.Element
+	inputs     []string
+	identities map[string]string
+	muted      map[string]bool
+	speaker    string
 }
 
-func BuildVideoBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) error {
+// sinkBinEgressTypes maps encoded output bin names to their egress type
+var sinkBinEgressTypes = map[string]types.EgressType{
+	"file":    types.EgressTypeFile,
+	"segment": types.EgressTypeSegments,
+	"stream":  types.EgressTypeStream,
+}
+
+func BuildVideoBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) (*VideoBin, error) {
 	b := &VideoBin{
 		bin:  pipeline.NewBin("video"),
 		conf: p,
@@ -59,29 +76,38 @@ func BuildVideoBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) error
 	switch p.SourceType {
 	case types.SourceTypeWeb:
 		if err := b.buildWebInput(); err != nil {
-			return err
+			return nil, err
+		}
+
+		if b.selector != nil {
+			// the slate is shown before the page is ready, and while chrome is relaunched
+			pipeline.AddOnTrackMuted(b.onTrackMuted)
+			pipeline.AddOnTrackUnmuted(b.onTrackUnmuted)
 		}
 
 	case types.SourceTypeSDK:
 		if err := b.buildSDKInput(); err != nil {
-			return err
+			return nil, err
 		}
 
 		pipeline.AddOnTrackAdded(b.onTrackAdded)
 		pipeline.AddOnTrackRemoved(b.onTrackRemoved)
 		pipeline.AddOnTrackMuted(b.onTrackMuted)
 		pipeline.AddOnTrackUnmuted(b.onTrackUnmuted)
+		if b.compositor != nil {
+			pipeline.AddOnActiveSpeakersChanged(b.onActiveSpeakersChanged)
+		}
 	}
 
 	var getPad func() *gst.Pad
 	if len(p.GetEncodedOutputs()) > 1 {
 		tee, err := gst.NewElementWithName("tee", "video_tee")
 		if err != nil {
-			return errors.ErrGstPipelineError(err)
+			return nil, errors.ErrGstPipelineError(err)
 		}
 
 		if err = b.bin.AddElement(tee); err != nil {
-			return err
+			return nil, err
 		}
 
 		getPad = func() *gst.Pad {
@@ -90,10 +116,10 @@ func BuildVideoBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) error
 	} else if len(p.GetEncodedOutputs()) > 0 {
 		queue, err := gstreamer.BuildQueue("video_queue", config.Latency, true)
 		if err != nil {
-			return errors.ErrGstPipelineError(err)
+			return nil, errors.ErrGstPipelineError(err)
 		}
 		if err = b.bin.AddElement(queue); err != nil {
-			return err
+			return nil, err
 		}
 
 		getPad = func() *gst.Pad {
@@ -104,6 +130,8 @@ func BuildVideoBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) error
 	b.bin.SetGetSinkPad(func(name string) *gst.Pad {
 		if strings.HasPrefix(name, "image") {
 			return b.rawVideoTee.GetRequestPad("src_%u")
+		} else if b.overlayTee != nil && p.Overlays.EnabledFor(sinkBinEgressTypes[name]) {
+			return b.overlayTee.GetRequestPad("src_%u")
 		} else if getPad != nil {
 			return getPad()
 		}
@@ -111,7 +139,11 @@ func BuildVideoBin(pipeline *gstreamer.Pipeline, p *config.PipelineConfig) error
 		return nil
 	})
 
-	return pipeline.AddSourceBin(b.bin)
+	if err := pipeline.AddSourceBin(b.bin); err != nil {
+		return nil, err
+	}
+
+	return b, nil
 }
 
 func (b *VideoBin) onTrackAdded(ts *config.TrackSource) {
@@ -140,7 +172,14 @@ func (b *VideoBin) onTrackRemoved(trackID string) {
 	delete(b.names, trackID)
 	delete(b.pads, name)
 
-	if b.selectedPad == name {
+	if b.compositor != nil {
+		b.removeCompositorInputLocked(name)
+		if err := b.updateLayoutLocked(); err != nil {
+			b.mu.Unlock()
+			b.bin.OnError(err)
+			return
+		}
+	} else if b.selectedPad == name {
 		if err := b.setSelectorPadLocked(videoTestSrcName); err != nil {
 			b.mu.Unlock()
 			b.bin.OnError(err)
@@ -160,7 +199,14 @@ func (b *VideoBin) onTrackMuted(trackID string) {
 	}
 
 	b.mu.Lock()
-	if name, ok := b.names[trackID]; ok && b.selectedPad == name {
+	if name, ok := b.names[trackID]; ok && b.compositor != nil {
+		b.muted[name] = true
+		if err := b.updateLayoutLocked(); err != nil {
+			b.mu.Unlock()
+			b.bin.OnError(err)
+			return
+		}
+	} else if ok && b.selectedPad == name {
 		if err := b.setSelectorPadLocked(videoTestSrcName); err != nil {
 			b.mu.Unlock()
 			b.bin.OnError(err)
@@ -176,7 +222,14 @@ func (b *VideoBin) onTrackUnmuted(trackID string) {
 	}
 
 	b.mu.Lock()
-	if name, ok := b.names[trackID]; ok {
+	if name, ok := b.names[trackID]; ok && b.compositor != nil {
+		delete(b.muted, name)
+		if err := b.updateLayoutLocked(); err != nil {
+			b.mu.Unlock()
+			b.bin.OnError(err)
+			return
+		}
+	} else if ok {
 		if err := b.setSelectorPadLocked(name); err != nil {
 			b.mu.Unlock()
 			b.bin.OnError(err)
@@ -200,6 +253,21 @@ func (b *VideoBin) buildWebInput() error {
 	if err = xImageSrc.SetProperty("show-pointer", false); err != nil {
 		return errors.ErrGstPipelineError(err)
 	}
+	if region := b.conf.CaptureRegion; region != nil {
+		// end coordinates are inclusive
+		if err = xImageSrc.SetProperty("startx", uint(region.X)); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = xImageSrc.SetProperty("starty", uint(region.Y)); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = xImageSrc.SetProperty("endx", uint(region.X+region.Width-1)); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = xImageSrc.SetProperty("endy", uint(region.Y+region.Height-1)); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+	}
 
 	videoQueue, err := gstreamer.BuildQueue("video_input_queue", config.Latency, true)
 	if err != nil {
@@ -219,19 +287,40 @@ func (b *VideoBin) buildWebInput() error {
 		return errors.ErrGstPipelineError(err)
 	}
 
-	caps, err := gst.NewElement("capsfilter")
-	if err != nil {
-		return errors.ErrGstPipelineError(err)
+	elements := []*gst.Element{xImageSrc, videoQueue, videoConvert}
+	if b.conf.CaptureRegion != nil {
+		// the cropped region is scaled to the output size
+		scaler, err := b.buildScaler(int(b.conf.CaptureRegion.Width), int(b.conf.CaptureRegion.Height))
+		if err != nil {
+			return err
+		}
+		elements = append(elements, scaler...)
 	}
-	if err = caps.SetProperty("caps", gst.NewCapsFromString(fmt.Sprintf(
-		"video/x-raw,framerate=%d/1",
-		b.conf.Framerate,
-	),
-	)); err != nil {
-		return errors.ErrGstPipelineError(err)
+	elements = append(elements, videoRate)
+
+	var caps *gst.Element
+	if b.conf.CaptureRegion != nil {
+		if caps, err = b.newVideoCapsFilter(true); err != nil {
+			return err
+		}
+	} else {
+		if caps, err = gst.NewElement("capsfilter"); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = caps.SetProperty("caps", gst.NewCapsFromString(fmt.Sprintf(
+			"video/x-raw,framerate=%d/1",
+			b.conf.Framerate,
+		))); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+	}
+	elements = append(elements, caps)
+
+	if b.conf.ShowsSlateOnRelaunch() || b.conf.StreamsSlateBeforeStart() {
+		return b.addWebSelector(elements)
 	}
 
-	if err = b.bin.AddElements(xImageSrc, videoQueue, videoConvert, videoRate, caps); err != nil {
+	if err = b.bin.AddElements(elements...); err != nil {
 		return err
 	}
 
@@ -242,10 +331,58 @@ func (b *VideoBin) buildWebInput() error {
 	return nil
 }
 
+// addWebSelector feeds the web input through a selector, so the slate can replace it before the page is ready
+// and while chrome is relaunched
+func (b *VideoBin) addWebSelector(elements []*gst.Element) error {
+	b.pads = make(map[string]*gst.Pad)
+	b.names = make(map[string]string)
+
+	if err := b.addSelector(); err != nil {
+		return err
+	}
+
+	webBin := b.bin.NewBin(webSrcName)
+	if err := webBin.AddElements(elements...); err != nil {
+		return err
+	}
+	b.createSrcPad(config.WebVideoTrackID, webSrcName)
+
+	b.bin.SetGetSrcPad(b.getSrcPad)
+	b.bin.SetEOSFunc(func() bool {
+		b.mu.Lock()
+		pad := b.pads[b.selectedPad]
+		b.mu.Unlock()
+
+		pad.SendEvent(gst.NewEOSEvent())
+		return false
+	})
+
+	if err := b.bin.AddSourceBin(webBin); err != nil {
+		return err
+	}
+	if err := b.addVideoTestSrcBin(); err != nil {
+		return err
+	}
+	initial := webSrcName
+	if b.conf.StreamsSlateBeforeStart() {
+		// unmuted once the page is ready
+		initial = videoTestSrcName
+	}
+	if err := b.setSelectorPad(initial); err != nil {
+		return err
+	}
+
+	return b.addDecodedVideoSink()
+}
+
 func (b *VideoBin) buildSDKInput() error {
 	b.pads = make(map[string]*gst.Pad)
 	b.names = make(map[string]string)
 
+	if b.conf.RequestType == types.RequestTypeRoomComposite {
+		return b.buildCompositorInput()
+	}
+
 	// add selector first so pads can be created
 	if b.conf.VideoDecoding {
 		if err := b.addSelector(); err != nil {
@@ -290,6 +427,10 @@ func (b *VideoBin) buildSDKInput() error {
 }
 
 func (b *VideoBin) addAppSrcBin(ts *config.TrackSource) error {
+	if b.compositor != nil {
+		return b.addCompositorAppSrcBin(ts)
+	}
+
 	name := fmt.Sprintf("%s_%d", ts.TrackID, b.nextID)
 	b.nextID++
 
@@ -452,7 +593,7 @@ func (b *VideoBin) buildAppSrcBin(ts *config.TrackSource, name string) (*gstream
 		return nil, errors.ErrNotSupported(string(ts.MimeType))
 	}
 
-	if err := b.addVideoConverter(appSrcBin); err != nil {
+	if err := b.addVideoConverter(appSrcBin, ts); err != nil {
 		return nil, err
 	}
 
@@ -465,14 +606,23 @@ func (b *VideoBin) addVideoTestSrcBin() error {
 		return err
 	}
 
-	videoTestSrc, err := gst.NewElement("videotestsrc")
-	if err != nil {
-		return errors.ErrGstPipelineError(err)
-	}
-	if err = videoTestSrc.SetProperty("is-live", true); err != nil {
-		return errors.ErrGstPipelineError(err)
+	var src []*gst.Element
+	if b.conf.Slate != nil {
+		var err error
+		if src, err = b.buildSlateSource(); err != nil {
+			return err
+		}
+	} else {
+		videoTestSrc, err := gst.NewElement("videotestsrc")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		if err = videoTestSrc.SetProperty("is-live", true); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		videoTestSrc.SetArg("pattern", "black")
+		src = append(src, videoTestSrc)
 	}
-	videoTestSrc.SetArg("pattern", "black")
 
 	queue, err := gstreamer.BuildQueue("video_test_src_queue", config.Latency, false)
 	if err != nil {
@@ -487,11 +637,19 @@ func (b *VideoBin) addVideoTestSrcBin() error {
 		return errors.ErrGstPipelineError(err)
 	}
 
-	if err = testSrcBin.AddElements(videoTestSrc, queue, caps); err != nil {
+	if err = testSrcBin.AddElements(append(src, queue, caps)...); err != nil {
 		return err
 	}
 
-	b.createTestSrcPad()
+	if b.compositor != nil {
+		// the background is shown until inputs are added
+		if err = b.createCompositorPad(videoTestSrcName); err != nil {
+			return err
+		}
+		b.setSlateActive(true)
+	} else {
+		b.createTestSrcPad()
+	}
 	return nil
 }
 
@@ -522,110 +680,290 @@ func (b *VideoBin) addSelector() error {
 	return nil
 }
 
-func (b *VideoBin) addEncoder() error {
-	videoQueue, err := gstreamer.BuildQueue("video_encoder_queue", config.Latency, false)
+// buildEncoder returns a queue, the overlays if enabled, and the encoder
+func (b *VideoBin) buildEncoder(name string, overlays bool) ([]*gst.Element, error) {
+	videoQueue, err := gstreamer.BuildQueue(fmt.Sprintf("%s_queue", name), config.Latency, false)
 	if err != nil {
-		return errors.ErrGstPipelineError(err)
+		return nil, errors.ErrGstPipelineError(err)
 	}
-	if err = b.bin.AddElement(videoQueue); err != nil {
-		return err
+	elements := []*gst.Element{videoQueue}
+
+	if overlays {
+		overlayElements, err := buildOverlays(b.conf)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, overlayElements...)
+	}
+
+	encoder, err := BuildVideoEncoder(b.conf, b.conf.KeyFrameInterval)
+	if err != nil {
+		return nil, err
 	}
+	return append(elements, encoder...), nil
+}
 
-	switch b.conf.VideoOutCodec {
-    // we only encode h264, the rest are too slow
+// BuildVideoEncoder returns the encoder, followed by a capsfilter or parser when needed. Intro and outro clips
+// are encoded with it too, so that they can be joined with the recording
+func BuildVideoEncoder(conf *config.PipelineConfig, keyframeInterval float64) ([]*gst.Element, error) {
+	keyframes := int(keyframeInterval * float64(conf.Framerate))
+
+	// every encoder is tuned for realtime
+	switch conf.VideoOutCodec {
 	case types.MimeTypeH264:
 		x264Enc, err := gst.NewElement("x264enc")
 		if err != nil {
-			return errors.ErrGstPipelineError(err)
+			return nil, errors.ErrGstPipelineError(err)
 		}
 		x264Enc.SetArg("speed-preset", "superfast")
 		x264Enc.SetArg("tune", "zerolatency")
 		// x264Enc.SetArg("sliced-threads", "true")
 
-		if b.conf.KeyFrameInterval != 0 {
-			keyframeInterval := uint(b.conf.KeyFrameInterval * float64(b.conf.Framerate))
-			if err = x264Enc.SetProperty("key-int-max", keyframeInterval); err != nil {
-				return errors.ErrGstPipelineError(err)
+		if keyframes != 0 {
+			if err = x264Enc.SetProperty("key-int-max", uint(keyframes)); err != nil {
+				return nil, errors.ErrGstPipelineError(err)
 			}
 		}
 
 		if err = x264Enc.SetProperty("threads", uint(0)); err != nil {
-			return errors.ErrGstPipelineError(err)
+			return nil, errors.ErrGstPipelineError(err)
 		}
 
-		bufCapacity := uint(2000) // 2s
-		if b.conf.GetSegmentConfig() != nil {
+		if conf.GetSegmentConfig() != nil {
 			// avoid key frames other than at segments boundaries as splitmuxsink can become inconsistent otherwise
 			if err = x264Enc.SetProperty("option-string", "scenecut=0"); err != nil {
-				return errors.ErrGstPipelineError(err)
+				return nil, errors.ErrGstPipelineError(err)
 			}
-			bufCapacity = uint(time.Duration(b.conf.GetSegmentConfig().SegmentDuration) * (time.Second / time.Millisecond))
-		}
-		if bufCapacity > 10000 {
-			// Max value allowed by gstreamer
-			bufCapacity = 10000
 		}
-		if err = x264Enc.SetProperty("vbv-buf-capacity", bufCapacity); err != nil {
-			return errors.ErrGstPipelineError(err)
+		if err = x264Enc.SetProperty("vbv-buf-capacity", getBufCapacity(conf)); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
 		}
-		if b.conf.GetStreamConfig() != nil {
+		if conf.GetStreamConfig() != nil {
 			x264Enc.SetArg("pass", "cbr")
 		}
-		if err = x264Enc.SetProperty("bitrate", uint(b.conf.VideoBitrate)); err != nil {
-			return errors.ErrGstPipelineError(err)
+		if err = x264Enc.SetProperty("bitrate", uint(conf.VideoBitrate)); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
 		}
 
 		caps, err := gst.NewElement("capsfilter")
 		if err != nil {
-			return errors.ErrGstPipelineError(err)
+			return nil, errors.ErrGstPipelineError(err)
 		}
 		if err = caps.SetProperty("caps", gst.NewCapsFromString(fmt.Sprintf(
 			"video/x-h264,profile=%s",
-			b.conf.VideoProfile,
+			conf.VideoProfile,
 		))); err != nil {
-			return errors.ErrGstPipelineError(err)
+			return nil, errors.ErrGstPipelineError(err)
 		}
 
-		if err = b.bin.AddElements(x264Enc, caps); err != nil {
-			return err
+		return []*gst.Element{x264Enc, caps}, nil
+
+	case types.MimeTypeH265:
+		x265Enc, err := gst.NewElement("x265enc")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		x265Enc.SetArg("speed-preset", "superfast")
+		x265Enc.SetArg("tune", "zerolatency")
+
+		if keyframes != 0 {
+			if err = x265Enc.SetProperty("key-int-max", keyframes); err != nil {
+				return nil, errors.ErrGstPipelineError(err)
+			}
+		}
+
+		// x265 rate control is only available through the option string
+		bufSize := uint(conf.VideoBitrate) * getBufCapacity(conf) / 1000
+		options := []string{fmt.Sprintf("vbv-maxrate=%d:vbv-bufsize=%d", conf.VideoBitrate, bufSize)}
+		if conf.GetSegmentConfig() != nil {
+			// avoid key frames other than at segments boundaries
+			options = append(options, "scenecut=0")
+		}
+		if err = x265Enc.SetProperty("option-string", strings.Join(options, ":")); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = x265Enc.SetProperty("bitrate", uint(conf.VideoBitrate)); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+
+		caps, err := gst.NewElement("capsfilter")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = caps.SetProperty("caps", gst.NewCapsFromString(
+			"video/x-h265,profile=main",
+		)); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
 		}
-		return nil
+
+		// x265enc only produces byte-stream, which mp4mux and matroskamux cannot accept
+		h265Parse, err := gst.NewElement("h265parse")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+
+		return []*gst.Element{x265Enc, caps, h265Parse}, nil
+
+	case types.MimeTypeVP8:
+		vp8Enc, err := gst.NewElement("vp8enc")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = setVPXProperties(conf, vp8Enc, keyframes); err != nil {
+			return nil, err
+		}
+		if err = vp8Enc.SetProperty("cpu-used", 8); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		return []*gst.Element{vp8Enc}, nil
 
 	case types.MimeTypeVP9:
 		vp9Enc, err := gst.NewElement("vp9enc")
 		if err != nil {
-			return errors.ErrGstPipelineError(err)
+			return nil, errors.ErrGstPipelineError(err)
 		}
-		if err = vp9Enc.SetProperty("deadline", int64(1)); err != nil {
-			return errors.ErrGstPipelineError(err)
+		if err = setVPXProperties(conf, vp9Enc, keyframes); err != nil {
+			return nil, err
+		}
+		if err = vp9Enc.SetProperty("cpu-used", 8); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
 		}
 		if err = vp9Enc.SetProperty("row-mt", true); err != nil {
-			return errors.ErrGstPipelineError(err)
+			return nil, errors.ErrGstPipelineError(err)
 		}
 		if err = vp9Enc.SetProperty("tile-columns", 3); err != nil {
-			return errors.ErrGstPipelineError(err)
+			return nil, errors.ErrGstPipelineError(err)
 		}
 		if err = vp9Enc.SetProperty("tile-rows", 1); err != nil {
-			return errors.ErrGstPipelineError(err)
+			return nil, errors.ErrGstPipelineError(err)
 		}
-		if err = vp9Enc.SetProperty("frame-parallel", true); err != nil {
-			return errors.ErrGstPipelineError(err)
+		if err = vp9Enc.SetProperty("frame-parallel-decoding", true); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
 		}
-		if err = vp9Enc.SetProperty("max-quantizer", 52); err != nil {
-			return errors.ErrGstPipelineError(err)
+		return []*gst.Element{vp9Enc}, nil
+
+	case types.MimeTypeAV1:
+		av1Enc, err := buildAV1Encoder(conf, keyframes)
+		if err != nil {
+			return nil, err
+		}
+
+		av1Parse, err := gst.NewElement("av1parse")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
 		}
-		if err = vp9Enc.SetProperty("min-quantizer", 2); err != nil {
+
+		return []*gst.Element{av1Enc, av1Parse}, nil
+
+	default:
+		return nil, errors.ErrNotSupported(fmt.Sprintf("%s encoding", conf.VideoOutCodec))
+	}
+}
+
+// setVPXProperties applies realtime rate control to vp8enc and vp9enc
+func setVPXProperties(conf *config.PipelineConfig, enc *gst.Element, keyframes int) error {
+	if err := enc.SetProperty("deadline", int64(1)); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	enc.SetArg("end-usage", "cbr")
+	if err := enc.SetProperty("target-bitrate", int(conf.VideoBitrate*1000)); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	if err := enc.SetProperty("buffer-size", int(getBufCapacity(conf))); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	if err := enc.SetProperty("threads", 4); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	if err := enc.SetProperty("max-quantizer", 52); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	if err := enc.SetProperty("min-quantizer", 2); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	if keyframes != 0 {
+		if err := enc.SetProperty("keyframe-max-dist", keyframes); err != nil {
 			return errors.ErrGstPipelineError(err)
 		}
-		if err = b.bin.AddElement(vp9Enc); err != nil {
-			return err
+	}
+	return nil
+}
+
+// buildAV1Encoder uses the first available av1 encoder, in order of realtime performance
+func buildAV1Encoder(conf *config.PipelineConfig, keyframes int) (*gst.Element, error) {
+	encoders := config.AV1Encoders
+	if conf.AV1Encoder != "" {
+		encoders = []string{conf.AV1Encoder}
+	}
+
+	var err error
+	for _, name := range encoders {
+		var av1Enc *gst.Element
+		if av1Enc, err = gst.NewElement(name); err != nil {
+			continue
 		}
+		if err = setAV1Properties(conf, av1Enc, name, keyframes); err != nil {
+			return nil, err
+		}
+		return av1Enc, nil
+	}
+	return nil, errors.ErrGstPipelineError(err)
+}
 
-		fallthrough
+func setAV1Properties(conf *config.PipelineConfig, av1Enc *gst.Element, name string, keyframes int) error {
+	var properties map[string]interface{}
+	switch name {
+	case "svtav1enc":
+		properties = map[string]interface{}{
+			"preset":         uint(12),
+			"target-bitrate": uint(conf.VideoBitrate),
+		}
+		if keyframes != 0 {
+			properties["intra-period-length"] = keyframes
+		}
 
-	default:
-		return errors.ErrNotSupported(fmt.Sprintf("%s encoding", b.conf.VideoOutCodec))
+	case "rav1enc":
+		properties = map[string]interface{}{
+			"speed-preset": uint(10),
+			"low-latency":  true,
+			"bitrate":      int(conf.VideoBitrate * 1000),
+		}
+		if keyframes != 0 {
+			properties["max-key-frame-interval"] = uint64(keyframes)
+		}
+
+	case "av1enc":
+		av1Enc.SetArg("usage-profile", "realtime")
+		av1Enc.SetArg("end-usage", "cbr")
+		properties = map[string]interface{}{
+			"cpu-used":       8,
+			"row-mt":         true,
+			"target-bitrate": uint(conf.VideoBitrate),
+		}
+		if keyframes != 0 {
+			properties["keyframe-max-dist"] = uint(keyframes)
+		}
 	}
+
+	for property, value := range properties {
+		if err := av1Enc.SetProperty(property, value); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+	}
+	return nil
+}
+
+// getBufCapacity returns the encoder buffer size in ms
+func getBufCapacity(conf *config.PipelineConfig) uint {
+	bufCapacity := uint(2000) // 2s
+	if o := conf.GetSegmentConfig(); o != nil {
+		bufCapacity = uint(time.Duration(o.SegmentDuration) * (time.Second / time.Millisecond))
+	}
+	if bufCapacity > 10000 {
+		// Max value allowed by gstreamer
+		bufCapacity = 10000
+	}
+	return bufCapacity
 }
 
 func (b *VideoBin) addDecodedVideoSink() error {
@@ -638,33 +976,92 @@ func (b *VideoBin) addDecodedVideoSink() error {
 		return err
 	}
 
-	if b.conf.VideoEncoding {
-		err = b.addEncoder()
-		if err != nil {
-			return err
-		}
+	if !b.conf.VideoEncoding {
+		return nil
 	}
 
-	return nil
-}
+	overlays, clean := b.getOverlayOutputs()
+	encoder, err := b.buildEncoder("video_encoder", len(overlays) > 0 && len(clean) == 0)
+	if err != nil {
+		return err
+	}
+	if err = b.bin.AddElements(encoder...); err != nil {
+		return err
+	}
+	if len(overlays) == 0 || len(clean) == 0 {
+		return nil
+	}
 
-func (b *VideoBin) addVideoConverter(bin *gstreamer.Bin) error {
-	videoQueue, err := gstreamer.BuildQueue("video_input_queue", config.Latency, true)
+	// outputs with overlays get a second encoder
+	overlayEncoder, err := b.buildEncoder("video_overlay_encoder", true)
+	if err != nil {
+		return err
+	}
+	b.overlayTee, err = gst.NewElementWithName("tee", "video_overlay_tee")
 	if err != nil {
 		return errors.ErrGstPipelineError(err)
 	}
+	return b.bin.AddBranch(b.rawVideoTee, append(overlayEncoder, b.overlayTee)...)
+}
 
-	videoConvert, err := gst.NewElement("videoconvert")
+// getOverlayOutputs returns the encoded outputs with and without overlays. Image outputs draw their own
+func (b *VideoBin) getOverlayOutputs() ([]types.EgressType, []types.EgressType) {
+	var overlays, clean []types.EgressType
+	for egressType := range b.conf.Outputs {
+		switch egressType {
+		case types.EgressTypeImages, types.EgressTypeWebsocket:
+			continue
+		}
+		if b.conf.Overlays.EnabledFor(egressType) {
+			overlays = append(overlays, egressType)
+		} else {
+			clean = append(clean, egressType)
+		}
+	}
+	return overlays, clean
+}
+
+func (b *VideoBin) addVideoConverter(bin *gstreamer.Bin, ts *config.TrackSource) error {
+	videoQueue, err := gstreamer.BuildQueue("video_input_queue", config.Latency, true)
 	if err != nil {
 		return errors.ErrGstPipelineError(err)
 	}
+	if layers := b.conf.VideoLayers; layers != nil {
+		// decoded caps change when the sfu switches simulcast layers. Switches are timed by the pts
+		// of the next frame, which is relative to the start of the recording
+		var width, height int
+		videoQueue.GetStaticPad("sink").AddProbe(gst.PadProbeTypeEventDownstream|gst.PadProbeTypeBuffer, func(_ *gst.Pad, info *gst.PadProbeInfo) gst.PadProbeReturn {
+			if event := info.GetEvent(); event != nil && event.Type() == gst.EventTypeCaps {
+				width, height, _ = getCapsSize(event.ParseCaps())
+			} else if buffer := info.GetBuffer(); buffer != nil && width > 0 {
+				if pts := buffer.PresentationTimestamp(); pts != gst.ClockTimeNone {
+					layers.OnResolution(ts.TrackID, width, height, time.Duration(pts))
+					width, height = 0, 0
+				}
+			}
+			return gst.PadProbeOK
+		})
+	}
 
-	videoScale, err := gst.NewElement("videoscale")
+	videoConvert, err := gst.NewElement("videoconvert")
 	if err != nil {
 		return errors.ErrGstPipelineError(err)
 	}
 
-	elements := []*gst.Element{videoQueue, videoConvert, videoScale}
+	elements := []*gst.Element{videoQueue, videoConvert}
+	if b.compositor != nil {
+		videoScale, err := gst.NewElement("videoscale")
+		if err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		elements = append(elements, videoScale)
+	} else {
+		scaler, err := b.buildScaler(int(ts.Width), int(ts.Height))
+		if err != nil {
+			return err
+		}
+		elements = append(elements, scaler...)
+	}
 
 	if !b.conf.VideoDecoding {
 		videoRate, err := gst.NewElement("videorate")
@@ -677,7 +1074,13 @@ func (b *VideoBin) addVideoConverter(bin *gstreamer.Bin) error {
 		elements = append(elements, videoRate)
 	}
 
-	caps, err := b.newVideoCapsFilter(!b.conf.VideoDecoding)
+	var caps *gst.Element
+	if b.compositor != nil {
+		// scaled by the compositor
+		caps, err = newCompositorInputCapsFilter()
+	} else {
+		caps, err = b.newVideoCapsFilter(!b.conf.VideoDecoding)
+	}
 	if err != nil {
 		return errors.ErrGstPipelineError(err)
 	}
@@ -792,5 +1195,6 @@ func (b *VideoBin) setSelectorPadLocked(name string) error {
 	}
 
 	b.selectedPad = name
+	b.setSlateActive(name == videoTestSrcName)
 	return nil
 }
@@ -0,0 +1,70 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"time"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/protocol/logger"
+)
+
+// loadCaptions adds cues from the caption source. With a refresh interval, the source is reloaded
+// until the pipeline stops, and cues appended to it are added
+func (c *Controller) loadCaptions() error {
+	loaded, err := c.addCaptions(0)
+	if err != nil {
+		return errors.ErrInvalidUrl("caption_source", err.Error())
+	}
+
+	if refresh := c.DataCapture.CaptionSourceRefresh; refresh > 0 {
+		go func() {
+			ticker := time.NewTicker(refresh)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-c.stopped.Watch():
+					return
+				case <-ticker.C:
+					if loaded, err = c.addCaptions(loaded); err != nil {
+						logger.Warnw("failed to reload caption source", err)
+					}
+				}
+			}
+		}()
+	}
+
+	return nil
+}
+
+// addCaptions adds any cues after the first loaded, returning the total loaded
+func (c *Controller) addCaptions(loaded int) (int, error) {
+	b, _, err := fetch(c.DataCapture.CaptionSource, captionContentTypes)
+	if err != nil {
+		return loaded, err
+	}
+
+	cues, err := config.ParseCaptions(b)
+	if err != nil {
+		return loaded, err
+	}
+	if len(cues) > loaded {
+		c.DataRecorder.AddCaptions(cues[loaded:])
+		loaded = len(cues)
+	}
+	return loaded, nil
+}
@@ -53,6 +53,7 @@ type Controller struct {
 	p         *gstreamer.Pipeline
 	sinks     map[types.EgressType][]sink.Sink
 	streamBin *builder.StreamBin
+	videoBin  *builder.VideoBin
 	callbacks *gstreamer.Callbacks
 
 	// internal
@@ -82,6 +83,9 @@ func New(ctx context.Context, conf *config.PipelineConfig, ipcServiceClient ipc.
 	}
 	c.callbacks.SetOnError(c.OnError)
 	c.callbacks.SetOnEOSSent(c.onEOSSent)
+	if conf.Quality != nil {
+		c.monitor.RegisterTrackQuality(conf.NodeID, conf.ClusterID, conf.Info.EgressId, conf.Quality)
+	}
 
 	// initialize gst
 	go func() {
@@ -93,7 +97,7 @@ func New(ctx context.Context, conf *config.PipelineConfig, ipcServiceClient ipc.
 	}()
 
 	// create source
-	c.src, err = source.New(ctx, conf, c.callbacks)
+	c.src, err = source.New(ctx, conf, c.callbacks, c.monitor)
 	if err != nil {
 		return nil, err
 	}
@@ -105,6 +109,11 @@ func New(ctx context.Context, conf *config.PipelineConfig, ipcServiceClient ipc.
 		return nil, err
 	}
 
+	if err = c.downloadOverlays(); err != nil {
+		c.src.Close()
+		return nil, errors.ErrInvalidUrl("overlays.images.url", err.Error())
+	}
+
 	// create pipeline
 	<-c.callbacks.GstReady
 	if err = c.BuildPipeline(); err != nil {
@@ -139,7 +148,7 @@ func (c *Controller) BuildPipeline() error {
 		}
 	}
 	if c.VideoEnabled {
-		if err = builder.BuildVideoBin(p, c.PipelineConfig); err != nil {
+		if c.videoBin, err = builder.BuildVideoBin(p, c.PipelineConfig); err != nil {
 			return err
 		}
 	}
@@ -210,7 +219,16 @@ func (c *Controller) Run(ctx context.Context) *info.EgressInfo {
 
 	// wait until room is ready
 	start := c.src.StartRecording()
-	if start != nil {
+	if start != nil && c.StreamsSlateBeforeStart() {
+		// stream the slate until the page is ready
+		go func() {
+			select {
+			case <-c.stopped.Watch():
+			case <-start:
+				c.callbacks.OnTrackUnmuted(config.WebVideoTrackID)
+			}
+		}()
+	} else if start != nil {
 		logger.Debugw("waiting for start signal")
 		select {
 		case <-c.stopped.Watch():
@@ -222,6 +240,14 @@ func (c *Controller) Run(ctx context.Context) *info.EgressInfo {
 		}
 	}
 
+	if c.DataRecorder != nil && c.DataCapture.CaptionSource != "" {
+		if err := c.loadCaptions(); err != nil {
+			c.src.Close()
+			c.Info.SetFailed(err)
+			return c.Info
+		}
+	}
+
 	for _, si := range c.sinks {
 		for _, s := range si {
 			if err := s.Start(); err != nil {
@@ -251,6 +277,23 @@ func (c *Controller) Run(ctx context.Context) *info.EgressInfo {
 		}
 	}
 
+	if c.Quality != nil {
+		if summary := c.Quality.Summary(); summary != "" {
+			if c.Info.Details != "" {
+				c.Info.Details += ", "
+			}
+			c.Info.Details += summary
+		}
+	}
+	if web, ok := c.src.(*source.WebSource); ok && web != nil {
+		if summary := web.CrashSummary(); summary != "" {
+			if c.Info.Details != "" {
+				c.Info.Details += ", "
+			}
+			c.Info.Details += summary
+		}
+	}
+
 	return c.Info
 }
 
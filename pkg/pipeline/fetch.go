@@ -0,0 +1,88 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/livekit/egress/pkg/types"
+)
+
+const (
+	fetchTimeout = time.Second * 10
+	maxFetchSize = 10 << 20
+)
+
+var (
+	// gdkpixbufoverlay decodes either
+	overlayContentTypes = []types.OutputType{types.OutputTypePNG, types.OutputTypeJPEG}
+	// srt files are often served as plain text
+	captionContentTypes = []types.OutputType{types.OutputTypeVTT, types.OutputTypeSRTCaptions, "text/plain"}
+)
+
+// fetch downloads a requested http(s) resource, returning its content type, which must be one of contentTypes
+func fetch(url string, contentTypes []types.OutputType) ([]byte, types.OutputType, error) {
+	client := &http.Client{Timeout: fetchTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !slices.Contains(contentTypes, types.OutputType(mediaType)) {
+		return nil, "", fmt.Errorf("unexpected content type %q from %s", resp.Header.Get("Content-Type"), url)
+	}
+	if resp.ContentLength > maxFetchSize {
+		return nil, "", fmt.Errorf("%s exceeds %d bytes", url, maxFetchSize)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(b) > maxFetchSize {
+		return nil, "", fmt.Errorf("%s exceeds %d bytes", url, maxFetchSize)
+	}
+	return b, types.OutputType(mediaType), nil
+}
+
+// downloadOverlays saves requested overlay images to the tmp dir
+func (c *Controller) downloadOverlays() error {
+	for i, image := range c.Overlays.Images {
+		b, contentType, err := fetch(image.Url, overlayContentTypes)
+		if err != nil {
+			return err
+		}
+
+		ext := types.FileExtensionForOutputType[contentType]
+		image.Filepath = path.Join(c.TmpDir, fmt.Sprintf("overlay_%d%s", i, ext))
+		if err = os.WriteFile(image.Filepath, b, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
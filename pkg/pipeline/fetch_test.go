@@ -0,0 +1,65 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/egress/pkg/types"
+)
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/logo":
+			w.Header().Set("Content-Type", "image/jpeg")
+			_, _ = w.Write([]byte("jpeg"))
+		case "/captions":
+			w.Header().Set("Content-Type", "text/vtt; charset=utf-8")
+			_, _ = w.Write([]byte("WEBVTT\n"))
+		case "/large":
+			w.Header().Set("Content-Type", "image/png")
+			// chunked, so the size is only known once read
+			for i := 0; i <= maxFetchSize/1024; i++ {
+				_, _ = w.Write([]byte(strings.Repeat("x", 1024)))
+				w.(http.Flusher).Flush()
+			}
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html></html>"))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	b, contentType, err := fetch(server.URL+"/logo", overlayContentTypes)
+	require.NoError(t, err)
+	require.Equal(t, types.OutputTypeJPEG, contentType)
+	require.Equal(t, "jpeg", string(b))
+
+	_, contentType, err = fetch(server.URL+"/captions", captionContentTypes)
+	require.NoError(t, err)
+	require.Equal(t, types.OutputTypeVTT, contentType)
+
+	_, _, err = fetch(server.URL+"/large", overlayContentTypes)
+	require.Error(t, err)
+
+	_, _, err = fetch(server.URL+"/page", overlayContentTypes)
+	require.Error(t, err)
+}
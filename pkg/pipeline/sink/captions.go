@@ -0,0 +1,76 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const webVTTHeader = "WEBVTT\n"
+
+var webVTTEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+type captionCue struct {
+	Start time.Duration
+	End   time.Duration
+	Voice string
+	Text  string
+}
+
+func formatWebVTTTimestamp(d time.Duration) string {
+	return formatCaptionTimestamp(d, '.')
+}
+
+func formatSRTTimestamp(d time.Duration) string {
+	return formatCaptionTimestamp(d, ',')
+}
+
+func formatCaptionTimestamp(d time.Duration, separator rune) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, separator, ms%1000)
+}
+
+func writeWebVTTCue(sb *strings.Builder, cue *captionCue) {
+	sb.WriteString("\n")
+	sb.WriteString(formatWebVTTTimestamp(cue.Start))
+	sb.WriteString(" --> ")
+	sb.WriteString(formatWebVTTTimestamp(cue.End))
+	sb.WriteString("\n")
+	if cue.Voice != "" {
+		// voice annotations end at the first '>'
+		sb.WriteString(fmt.Sprintf("<v %s>", webVTTEscaper.Replace(cue.Voice)))
+	}
+	sb.WriteString(webVTTEscaper.Replace(cue.Text))
+	sb.WriteString("\n")
+}
+
+// writeSRTCue writes a numbered cue, starting from 1
+func writeSRTCue(sb *strings.Builder, index int, cue *captionCue) {
+	if index > 1 {
+		sb.WriteString("\n")
+	}
+	sb.WriteString(fmt.Sprintf("%d\n%s --> %s\n", index, formatSRTTimestamp(cue.Start), formatSRTTimestamp(cue.End)))
+	if cue.Voice != "" {
+		sb.WriteString(cue.Voice)
+		sb.WriteString(": ")
+	}
+	sb.WriteString(cue.Text)
+	sb.WriteString("\n")
+}
@@ -0,0 +1,911 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-gst/go-gst/gst"
+	"github.com/go-gst/go-gst/gst/pbutils"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/pipeline/builder"
+	"github.com/livekit/egress/pkg/pipeline/sink/m3u8"
+	"github.com/livekit/egress/pkg/types"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	clipProbeTimeout = time.Second * 10
+	// encoding is faster than realtime, this only catches stalled pipelines
+	minClipEncodeTimeout = time.Minute
+)
+
+type clipInfo struct {
+	uri      string
+	location string // local path, for encoded clips
+	duration time.Duration
+	audio    bool
+	video    bool
+}
+
+func probeClip(uri string) (*clipInfo, error) {
+	discoverer, err := pbutils.NewDiscoverer(gst.ClockTime(uint64(clipProbeTimeout)))
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	info, err := discoverer.DiscoverURI(uri)
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if info.GetResult() != pbutils.DiscovererResultOK {
+		return nil, errors.ErrGstPipelineError(fmt.Errorf("could not read %s", uri))
+	}
+
+	return &clipInfo{
+		uri:      uri,
+		duration: time.Duration(info.GetDuration()),
+		audio:    len(info.GetAudioStreams()) > 0,
+		video:    len(info.GetVideoStreams()) > 0,
+	}, nil
+}
+
+func getFileURI(location string) string {
+	if abs, err := filepath.Abs(location); err == nil {
+		location = abs
+	}
+	u := &url.URL{Scheme: "file", Path: location}
+	return u.String()
+}
+
+// clipOutput receives the encoded streams of a clip pipeline
+type clipOutput struct {
+	elements []*gst.Element // linked in order, streams are linked to the first one
+	videoPad string         // request pad templates, streams are linked by caps when empty
+	audioPad string
+}
+
+func (o *clipOutput) link(src *gst.Element, padTemplate string) error {
+	if padTemplate == "" {
+		if err := src.Link(o.elements[0]); err != nil {
+			return errors.ErrGstPipelineError(err)
+		}
+		return nil
+	}
+	return linkRequestPad(src, o.elements[0], padTemplate)
+}
+
+// buildClipPipeline decodes each clip, normalizes it to the output parameters, and concatenates them into
+// the output. Streams missing from a clip are filled with black frames or silence.
+func buildClipPipeline(p *config.PipelineConfig, clips []*clipInfo, output *clipOutput, keyframeInterval float64) (*gst.Pipeline, error) {
+	videoCaps := fmt.Sprintf(
+		"video/x-raw,format=I420,width=%d,height=%d,framerate=%d/1,pixel-aspect-ratio=1/1",
+		p.Width, p.Height, p.Framerate,
+	)
+	rate, channels := p.AudioFrequency, p.AudioChannels
+	if p.AudioOutCodec == types.MimeTypeOpus {
+		rate, channels = 48000, 2
+	}
+	audioCaps := fmt.Sprintf("audio/x-raw,format=S16LE,layout=interleaved,rate=%d,channels=%d", rate, channels)
+
+	pipeline, err := newClipPipeline(output)
+	if err != nil {
+		return nil, err
+	}
+
+	var videoConcat, audioConcat *gst.Element
+	if p.VideoEnabled {
+		enc, err := builder.BuildVideoEncoder(p, keyframeInterval)
+		if err != nil {
+			return nil, err
+		}
+		if videoConcat, err = addClipConcat(pipeline, output, output.videoPad, enc...); err != nil {
+			return nil, err
+		}
+	}
+	if p.AudioEnabled {
+		enc, err := getClipAudioEncoder(p)
+		if err != nil {
+			return nil, err
+		}
+		caps, err := newCapsFilter(audioCaps)
+		if err != nil {
+			return nil, err
+		}
+		if audioConcat, err = addClipConcat(pipeline, output, output.audioPad, append([]*gst.Element{caps}, enc...)...); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, clip := range clips {
+		// decoded pads are linked to the first element of each branch
+		var videoSink, audioSink *gst.Element
+
+		if p.VideoEnabled {
+			if clip.video {
+				branch, err := gst.NewElementMany("videoconvert", "videoscale", "videorate")
+				if err != nil {
+					return nil, errors.ErrGstPipelineError(err)
+				}
+				if videoSink, err = addClipBranch(pipeline, videoConcat, videoCaps, branch...); err != nil {
+					return nil, err
+				}
+			} else {
+				frames := int(math.Ceil(clip.duration.Seconds() * float64(p.Framerate)))
+				src, err := newClipElement("videotestsrc", "pattern", "black", "num-buffers", strconv.Itoa(frames))
+				if err != nil {
+					return nil, err
+				}
+				if _, err = addClipBranch(pipeline, videoConcat, videoCaps, src); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if p.AudioEnabled {
+			if clip.audio {
+				branch, err := gst.NewElementMany("audioconvert", "audioresample")
+				if err != nil {
+					return nil, errors.ErrGstPipelineError(err)
+				}
+				if audioSink, err = addClipBranch(pipeline, audioConcat, audioCaps, branch...); err != nil {
+					return nil, err
+				}
+			} else {
+				// 10ms buffers
+				samples := int(rate / 100)
+				buffers := int(math.Ceil(clip.duration.Seconds() * 100))
+				src, err := newClipElement(
+					"audiotestsrc", "wave", "silence", "samplesperbuffer", strconv.Itoa(samples), "num-buffers", strconv.Itoa(buffers),
+				)
+				if err != nil {
+					return nil, err
+				}
+				if _, err = addClipBranch(pipeline, audioConcat, audioCaps, src); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if videoSink != nil || audioSink != nil {
+			decoder, err := gst.NewElement("uridecodebin")
+			if err != nil {
+				return nil, errors.ErrGstPipelineError(err)
+			}
+			// the uri comes from the request, so it is only ever set as a property
+			if err = decoder.SetProperty("uri", clip.uri); err != nil {
+				return nil, errors.ErrGstPipelineError(err)
+			}
+			if err = pipeline.Add(decoder); err != nil {
+				return nil, errors.ErrGstPipelineError(err)
+			}
+			if err = linkClipPads(pipeline, decoder, videoSink, audioSink); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return pipeline, nil
+}
+
+// buildRemuxPipeline concatenates encoded files into the output, without decoding them
+func buildRemuxPipeline(p *config.PipelineConfig, locations []string, output *clipOutput) (*gst.Pipeline, error) {
+	pipeline, err := newClipPipeline(output)
+	if err != nil {
+		return nil, err
+	}
+
+	var videoCaps, audioCaps string
+	var videoConcat, audioConcat *gst.Element
+	if p.VideoEnabled {
+		var parser string
+		videoCaps, parser = getEncodedVideoCaps(p.VideoOutCodec)
+		if videoCaps == "" {
+			return nil, errors.ErrNotSupported(fmt.Sprintf("%s intro and outro", p.VideoOutCodec))
+		}
+		parse, err := newClipElements(newClipParser(parser))
+		if err != nil {
+			return nil, err
+		}
+		if videoConcat, err = addClipConcat(pipeline, output, output.videoPad, parse...); err != nil {
+			return nil, err
+		}
+	}
+	if p.AudioEnabled {
+		var parser string
+		audioCaps, parser = getEncodedAudioCaps(p.AudioOutCodec)
+		if audioCaps == "" {
+			return nil, errors.ErrNotSupported(fmt.Sprintf("%s intro and outro", p.AudioOutCodec))
+		}
+		parse, err := newClipElements(newClipParser(parser))
+		if err != nil {
+			return nil, err
+		}
+		if audioConcat, err = addClipConcat(pipeline, output, output.audioPad, parse...); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, location := range locations {
+		src, err := gst.NewElement("filesrc")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = src.SetProperty("location", location); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		parse, err := gst.NewElement("parsebin")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = addClipChain(pipeline, src, parse); err != nil {
+			return nil, err
+		}
+
+		var videoSink, audioSink *gst.Element
+		if p.VideoEnabled {
+			if videoSink, err = addClipBranch(pipeline, videoConcat, videoCaps); err != nil {
+				return nil, err
+			}
+		}
+		if p.AudioEnabled {
+			if audioSink, err = addClipBranch(pipeline, audioConcat, audioCaps); err != nil {
+				return nil, err
+			}
+		}
+		if err = linkClipPads(pipeline, parse, videoSink, audioSink); err != nil {
+			return nil, err
+		}
+	}
+
+	return pipeline, nil
+}
+
+// newClipPipeline creates a pipeline, adding the output
+func newClipPipeline(output *clipOutput) (*gst.Pipeline, error) {
+	pipeline, err := gst.NewPipeline("clips")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = addClipChain(pipeline, output.elements...); err != nil {
+		return nil, err
+	}
+	return pipeline, nil
+}
+
+// addClipConcat adds a concat element, which is linked to the output through a queue and the given elements
+func addClipConcat(pipeline *gst.Pipeline, output *clipOutput, padTemplate string, elements ...*gst.Element) (*gst.Element, error) {
+	chain, err := gst.NewElementMany("concat", "queue")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	chain = append(chain, elements...)
+	if err = addClipChain(pipeline, chain...); err != nil {
+		return nil, err
+	}
+	if err = output.link(chain[len(chain)-1], padTemplate); err != nil {
+		return nil, err
+	}
+	return chain[0], nil
+}
+
+// addClipBranch links the elements to the next concat pad through a capsfilter and a queue, returning the first element
+func addClipBranch(pipeline *gst.Pipeline, concat *gst.Element, caps string, elements ...*gst.Element) (*gst.Element, error) {
+	capsFilter, err := newCapsFilter(caps)
+	if err != nil {
+		return nil, err
+	}
+	queue, err := gst.NewElement("queue")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	elements = append(elements, capsFilter, queue)
+	if err = addClipChain(pipeline, elements...); err != nil {
+		return nil, err
+	}
+	// concat plays its pads in the order they were requested
+	if err = linkRequestPad(queue, concat, "sink_%u"); err != nil {
+		return nil, err
+	}
+	return elements[0], nil
+}
+
+// linkClipPads links each pad added by a decodebin or parsebin to the video or audio branch.
+// Streams without a branch are discarded
+func linkClipPads(pipeline *gst.Pipeline, src, video, audio *gst.Element) error {
+	_, err := src.Connect("pad-added", func(self *gst.Element, pad *gst.Pad) {
+		var sink *gst.Element
+		if caps := pad.GetCurrentCaps(); caps != nil && caps.GetSize() > 0 {
+			switch name := caps.GetStructureAt(0).Name(); {
+			case strings.HasPrefix(name, "video/"):
+				sink = video
+			case strings.HasPrefix(name, "audio/"):
+				sink = audio
+			}
+		}
+
+		if sink == nil || sink.GetStaticPad("sink").IsLinked() {
+			fakeSink, err := gst.NewElement("fakesink")
+			if err == nil {
+				err = pipeline.Add(fakeSink)
+			}
+			if err != nil {
+				pipeline.GetPipelineBus().Post(gst.NewErrorMessage(self, err, "", nil))
+				return
+			}
+			fakeSink.SyncStateWithParent()
+			sink = fakeSink
+		}
+
+		if padReturn := pad.Link(sink.GetStaticPad("sink")); padReturn != gst.PadLinkOK {
+			err := errors.ErrPadLinkFailed(self.GetName(), sink.GetName(), padReturn.String())
+			pipeline.GetPipelineBus().Post(gst.NewErrorMessage(self, err, "", nil))
+		}
+	})
+	if err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	return nil
+}
+
+// addClipChain adds the elements to the pipeline, linking them in order
+func addClipChain(pipeline *gst.Pipeline, elements ...*gst.Element) error {
+	if err := pipeline.AddMany(elements...); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	if err := gst.ElementLinkMany(elements...); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	return nil
+}
+
+// linkRequestPad links the src pad of src to a new request pad on sink
+func linkRequestPad(src, sink *gst.Element, padTemplate string) error {
+	pad := sink.GetRequestPad(padTemplate)
+	if pad == nil {
+		return errors.ErrGstPipelineError(fmt.Errorf("failed to request %s pad from %s", padTemplate, sink.GetName()))
+	}
+	if padReturn := src.GetStaticPad("src").Link(pad); padReturn != gst.PadLinkOK {
+		return errors.ErrPadLinkFailed(src.GetName(), sink.GetName(), padReturn.String())
+	}
+	return nil
+}
+
+// newClipElement creates an element, setting properties from strings the way gst-launch does.
+// Only values from the node config and encoding options are set this way, never locations from the request
+func newClipElement(factory string, args ...string) (*gst.Element, error) {
+	element, err := gst.NewElement(factory)
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	for i := 0; i+1 < len(args); i += 2 {
+		element.SetArg(args[i], args[i+1])
+	}
+	return element, nil
+}
+
+// newClipParser creates a parser, or nil if the stream doesn't need one
+func newClipParser(factory string) (*gst.Element, error) {
+	if factory == "" {
+		return nil, nil
+	}
+	return newClipElement(factory)
+}
+
+// newClipElements returns the element as a chain, which is empty if the element is nil
+func newClipElements(element *gst.Element, err error) ([]*gst.Element, error) {
+	if err != nil || element == nil {
+		return nil, err
+	}
+	return []*gst.Element{element}, nil
+}
+
+func newCapsFilter(caps string) (*gst.Element, error) {
+	capsFilter, err := gst.NewElement("capsfilter")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = capsFilter.SetProperty("caps", gst.NewCapsFromString(caps)); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	return capsFilter, nil
+}
+
+// getEncodedVideoCaps returns the caps and parser for each output codec
+func getEncodedVideoCaps(codec types.MimeType) (string, string) {
+	switch codec {
+	case types.MimeTypeH264:
+		return "video/x-h264", "h264parse"
+	case types.MimeTypeH265:
+		return "video/x-h265", "h265parse"
+	case types.MimeTypeVP8:
+		return "video/x-vp8", ""
+	case types.MimeTypeVP9:
+		return "video/x-vp9", ""
+	case types.MimeTypeAV1:
+		return "video/x-av1", "av1parse"
+	default:
+		return "", ""
+	}
+}
+
+func getEncodedAudioCaps(codec types.MimeType) (string, string) {
+	switch codec {
+	case types.MimeTypeOpus:
+		return "audio/x-opus", "opusparse"
+	case types.MimeTypeAAC:
+		return "audio/mpeg,mpegversion=4", "aacparse"
+	case types.MimeTypeFLAC:
+		return "audio/x-flac", "flacparse"
+	case types.MimeTypeMP3:
+		return "audio/mpeg,mpegversion=1", "mpegaudioparse"
+	case types.MimeTypeRawAudio:
+		return "audio/x-raw", ""
+	default:
+		return "", ""
+	}
+}
+
+func getClipAudioEncoder(p *config.PipelineConfig) ([]*gst.Element, error) {
+	switch p.AudioOutCodec {
+	case types.MimeTypeOpus:
+		return newClipEncoder("opusenc", []string{"bitrate", strconv.Itoa(int(p.AudioBitrate) * 1000)}, "", "")
+	case types.MimeTypeAAC:
+		return newClipEncoder("faac", []string{"bitrate", strconv.Itoa(int(p.AudioBitrate) * 1000)}, "", "")
+	case types.MimeTypeFLAC:
+		return newClipEncoder("flacenc", nil, "", "")
+	case types.MimeTypeMP3:
+		return newClipEncoder("lamemp3enc", []string{"target", "bitrate", "bitrate", strconv.Itoa(int(p.AudioBitrate)), "cbr", "true"}, "", "")
+	case types.MimeTypeRawAudio:
+		return nil, nil
+	default:
+		return nil, errors.ErrNotSupported(fmt.Sprintf("%s intro and outro", p.AudioOutCodec))
+	}
+}
+
+// newClipEncoder returns the encoder, followed by a capsfilter and parser when set
+func newClipEncoder(factory string, args []string, caps, parser string) ([]*gst.Element, error) {
+	enc, err := newClipElement(factory, args...)
+	if err != nil {
+		return nil, err
+	}
+	elements := []*gst.Element{enc}
+	if caps != "" {
+		capsFilter, err := newCapsFilter(caps)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, capsFilter)
+	}
+	if parser != "" {
+		parse, err := newClipElement(parser)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, parse)
+	}
+	return elements, nil
+}
+
+// getClipFileOutput returns a muxer writing to location
+func getClipFileOutput(outputType types.OutputType, location string) (*clipOutput, error) {
+	var factory string
+	switch outputType {
+	case types.OutputTypeWAV:
+		factory = "wavenc"
+	case types.OutputTypeFLAC:
+		// rejected with the request, the joined file would keep the first clip's STREAMINFO
+		return nil, errors.ErrNotSupported("flac intro and outro")
+	case types.OutputTypeMP3:
+		// encoded streams are written directly
+	case types.OutputTypeOGG:
+		factory = "oggmux"
+	case types.OutputTypeIVF:
+		factory = "avmux_ivf"
+	case types.OutputTypeMP4:
+		factory = "mp4mux"
+	case types.OutputTypeWebM:
+		factory = "webmmux"
+	case types.OutputTypeMKV:
+		factory = "matroskamux"
+	default:
+		return nil, errors.ErrNotSupported(fmt.Sprintf("%s intro and outro", outputType))
+	}
+
+	sink, err := gst.NewElement("filesink")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = sink.SetProperty("location", location); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if factory == "" {
+		return &clipOutput{elements: []*gst.Element{sink}}, nil
+	}
+
+	mux, err := gst.NewElement(factory)
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	return &clipOutput{elements: []*gst.Element{mux, sink}}, nil
+}
+
+func runClipPipeline(pipeline *gst.Pipeline, timeout time.Duration) error {
+	defer func() {
+		_ = pipeline.SetState(gst.StateNull)
+	}()
+
+	if err := pipeline.SetState(gst.StatePlaying); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+
+	msg := pipeline.GetPipelineBus().TimedPopFiltered(gst.ClockTime(uint64(timeout)), gst.MessageEOS|gst.MessageError)
+	switch {
+	case msg == nil:
+		return errors.ErrGstPipelineError(errors.New("intro and outro encoding timed out"))
+	case msg.Type() == gst.MessageError:
+		return errors.ErrGstPipelineError(msg.ParseError())
+	default:
+		return nil
+	}
+}
+
+func getClipEncodeTimeout(clips []*clipInfo) time.Duration {
+	var duration time.Duration
+	for _, clip := range clips {
+		duration += clip.duration
+	}
+	return max(duration*2, minClipEncodeTimeout)
+}
+
+// probeClips returns the intro and outro, which are nil when not configured
+func probeClips(introURI, outroURI string) (*clipInfo, *clipInfo, error) {
+	var intro, outro *clipInfo
+	var err error
+	if introURI != "" {
+		if intro, err = probeClip(introURI); err != nil {
+			return nil, nil, err
+		}
+	}
+	if outroURI != "" {
+		if outro, err = probeClip(outroURI); err != nil {
+			return nil, nil, err
+		}
+	}
+	return intro, outro, nil
+}
+
+// fileClips are the intro and outro, encoded like the recording so that it can be remuxed between them
+type fileClips struct {
+	intro *clipInfo
+	outro *clipInfo
+	err   error
+}
+
+// encodeClips encodes the intro and outro to {filepath}.intro and {filepath}.outro
+func (s *FileSink) encodeClips(introURI, outroURI string) *fileClips {
+	intro, outro, err := probeClips(introURI, outroURI)
+	if err != nil {
+		return &fileClips{err: err}
+	}
+
+	clips := &fileClips{}
+	if intro != nil {
+		clips.intro, clips.err = s.encodeClipFile(intro, "intro")
+	}
+	if outro != nil && clips.err == nil {
+		clips.outro, clips.err = s.encodeClipFile(outro, "outro")
+	}
+	return clips
+}
+
+func (s *FileSink) encodeClipFile(clip *clipInfo, name string) (*clipInfo, error) {
+	location := fmt.Sprintf("%s.%s", s.LocalFilepath, name)
+	output, err := getClipFileOutput(s.OutputType, location)
+	if err != nil {
+		return nil, err
+	}
+	pipeline, err := buildClipPipeline(s.conf, []*clipInfo{clip}, output, s.conf.KeyFrameInterval)
+	if err != nil {
+		return nil, err
+	}
+	if err = runClipPipeline(pipeline, getClipEncodeTimeout([]*clipInfo{clip})); err != nil {
+		_ = os.Remove(location)
+		return nil, err
+	}
+
+	encoded, err := probeClip(getFileURI(location))
+	if err != nil {
+		return nil, err
+	}
+	encoded.location = location
+	return encoded, nil
+}
+
+// addClips remuxes the recording between the encoded intro and outro
+func (s *FileSink) addClips(clips *fileClips) error {
+	var locations []string
+	var introDuration, outroDuration time.Duration
+	if clips.intro != nil {
+		locations = append(locations, clips.intro.location)
+		introDuration = clips.intro.duration
+	}
+	locations = append(locations, s.LocalFilepath)
+	if clips.outro != nil {
+		locations = append(locations, clips.outro.location)
+		outroDuration = clips.outro.duration
+	}
+
+	location := fmt.Sprintf("%s.clips", s.LocalFilepath)
+	output, err := getClipFileOutput(s.OutputType, location)
+	if err != nil {
+		return err
+	}
+	pipeline, err := buildRemuxPipeline(s.conf, locations, output)
+	if err != nil {
+		return err
+	}
+
+	// remuxing is much faster than realtime
+	timeout := max(time.Duration(s.FileInfo.Duration)+introDuration+outroDuration, minClipEncodeTimeout)
+	if err = runClipPipeline(pipeline, timeout); err != nil {
+		_ = os.Remove(location)
+		return err
+	}
+	if err = os.Rename(location, s.LocalFilepath); err != nil {
+		return err
+	}
+
+	logger.Debugw("added intro and outro", "intro", introDuration, "outro", outroDuration)
+	s.ContentOffset = introDuration
+	s.FileInfo.Duration += int64(introDuration + outroDuration)
+	return nil
+}
+
+func (c *fileClips) remove() {
+	for _, clip := range []*clipInfo{c.intro, c.outro} {
+		if clip != nil {
+			_ = os.Remove(clip.location)
+		}
+	}
+}
+
+// clipSegment is an intro or outro segment, which is added to the playlists around the live segments
+type clipSegment struct {
+	filename string
+	duration time.Duration
+	fragment *m3u8.Fragment // set for fmp4 segments
+}
+
+// encodeClipSegments encodes a clip as {prefix}_{name}_00000.ts, {prefix}_{name}_00001.ts, ...
+// using the same segment type as the live segments
+func (s *SegmentSink) encodeClipSegments(clip *clipInfo, name string) ([]*clipSegment, error) {
+	prefix := fmt.Sprintf("%s_%s", s.SegmentPrefix, name)
+	ext := types.FileExtensionForOutputType[s.outputType]
+	output, err := s.getClipSegmentOutput(prefix, ext)
+	if err != nil {
+		return nil, err
+	}
+	pipeline, err := buildClipPipeline(s.conf, []*clipInfo{clip}, output, float64(s.SegmentDuration))
+	if err != nil {
+		return nil, err
+	}
+	if err = runClipPipeline(pipeline, getClipEncodeTimeout([]*clipInfo{clip})); err != nil {
+		return nil, err
+	}
+
+	var segments []*clipSegment
+	for i := 0; ; i++ {
+		filename := fmt.Sprintf("%s_%05d%s", prefix, i, ext)
+		localPath := path.Join(s.LocalDir, filename)
+		if _, err = os.Stat(localPath); err != nil {
+			break
+		}
+		segment, err := probeClip(getFileURI(localPath))
+		if err != nil {
+			return nil, err
+		}
+		var fragment *m3u8.Fragment
+		if s.outputType == types.OutputTypeM4S {
+			if fragment, err = m3u8.ReadFragment(localPath); err != nil {
+				return nil, err
+			}
+		}
+		segments = append(segments, &clipSegment{
+			filename: filename,
+			duration: segment.duration,
+			fragment: fragment,
+		})
+	}
+	if len(segments) == 0 {
+		return nil, errors.ErrGstPipelineError(fmt.Errorf("no %s segments written", name))
+	}
+	return segments, nil
+}
+
+// getClipSegmentOutput returns a splitmuxsink writing {prefix}_00000{ext}, {prefix}_00001{ext}, ...
+// The prefix comes from the request, so filenames are returned by format-location instead of a location pattern
+func (s *SegmentSink) getClipSegmentOutput(prefix string, ext types.FileExtension) (*clipOutput, error) {
+	sink, err := gst.NewElement("splitmuxsink")
+	if err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = sink.SetProperty("max-size-time", uint64(time.Duration(s.SegmentDuration)*time.Second)); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if err = sink.SetProperty("send-keyframe-requests", true); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if s.outputType == types.OutputTypeM4S {
+		mux, err := gst.NewElement("mp4mux")
+		if err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = mux.SetProperty("fragment-duration", uint(s.SegmentDuration*1000)); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = mux.SetProperty("streamable", true); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+		if err = sink.SetProperty("muxer", mux); err != nil {
+			return nil, errors.ErrGstPipelineError(err)
+		}
+	} else if err = sink.SetProperty("muxer-factory", "mpegtsmux"); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+	if _, err = sink.Connect("format-location", func(_ *gst.Element, fragmentID uint) string {
+		return path.Join(s.LocalDir, fmt.Sprintf("%s_%05d%s", prefix, fragmentID, ext))
+	}); err != nil {
+		return nil, errors.ErrGstPipelineError(err)
+	}
+
+	return &clipOutput{elements: []*gst.Element{sink}, videoPad: "video", audioPad: "audio_%u"}, nil
+}
+
+// encodeClips encodes the intro and then the outro segments, which is started with the recording.
+// Each is ready as soon as it is encoded, so the live playlist never waits for the outro
+func (s *SegmentSink) encodeClips(introURI, outroURI string) {
+	var err error
+	if introURI != "" {
+		if s.intro, err = s.encodeClip(introURI, "intro"); err != nil {
+			logger.Warnw("failed to add intro", err)
+		}
+		close(s.introReady)
+	}
+	if outroURI != "" {
+		if s.outro, err = s.encodeClip(outroURI, "outro"); err != nil {
+			logger.Warnw("failed to add outro", err)
+		}
+		close(s.outroReady)
+	}
+}
+
+func (s *SegmentSink) encodeClip(uri, name string) ([]*clipSegment, error) {
+	clip, err := probeClip(uri)
+	if err != nil {
+		return nil, err
+	}
+	return s.encodeClipSegments(clip, name)
+}
+
+// appendIntro adds the intro ahead of the first live segment, which starts at next. The intro is usually
+// encoded before the first live segment closes, otherwise playlist updates wait for it
+func (s *SegmentSink) appendIntro(next time.Time) error {
+	if s.introAppended || s.introReady == nil {
+		return nil
+	}
+	s.introAppended = true
+
+	<-s.introReady
+	if len(s.intro) == 0 {
+		return nil
+	}
+
+	duration := getClipSegmentsDuration(s.intro)
+	if err := s.appendClipSegments(s.intro, next.Add(-duration)); err != nil {
+		return err
+	}
+	s.ContentOffset.Store(duration)
+	s.setDiscontinuity()
+	return nil
+}
+
+// appendOutro adds the outro after the last live segment, returning its duration
+func (s *SegmentSink) appendOutro() (time.Duration, error) {
+	if s.outroReady == nil {
+		return 0, nil
+	}
+
+	<-s.outroReady
+	if len(s.outro) == 0 {
+		return 0, nil
+	}
+
+	start := s.lastSegmentEnd
+	if start.IsZero() {
+		start = time.Now()
+	}
+	s.setDiscontinuity()
+	if err := s.appendClipSegments(s.outro, start); err != nil {
+		return 0, err
+	}
+	return getClipSegmentsDuration(s.outro), nil
+}
+
+func getClipSegmentsDuration(segments []*clipSegment) time.Duration {
+	var duration time.Duration
+	for _, segment := range segments {
+		duration += segment.duration
+	}
+	return duration
+}
+
+func (s *SegmentSink) appendClipSegments(segments []*clipSegment, dateTime time.Time) error {
+	for _, segment := range segments {
+		_, size, err := s.Upload(
+			path.Join(s.LocalDir, segment.filename),
+			path.Join(s.StorageDir, segment.filename),
+			s.outputType, true, "segment",
+		)
+		if err != nil {
+			return err
+		}
+
+		s.infoLock.Lock()
+		s.SegmentsInfo.SegmentCount++
+		s.SegmentsInfo.Size += size
+		s.infoLock.Unlock()
+
+		duration := segment.duration.Seconds()
+		if err = appendSegment(s.playlist, dateTime, duration, segment.filename, segment.fragment); err != nil {
+			return err
+		}
+		if s.livePlaylist != nil {
+			if err = appendSegment(s.livePlaylist, dateTime, duration, segment.filename, segment.fragment); err != nil {
+				return err
+			}
+		}
+		if s.subtitles != nil {
+			// keeps the rendition aligned with the media playlist
+			if err = s.writeSubtitles(dateTime, duration, segment.filename, webVTTHeader); err != nil {
+				return err
+			}
+		}
+		dateTime = dateTime.Add(segment.duration)
+	}
+	return nil
+}
+
+// setDiscontinuity marks the next segment in each playlist, since clip timestamps restart from zero
+func (s *SegmentSink) setDiscontinuity() {
+	s.playlist.Discontinuity()
+	if s.livePlaylist != nil {
+		s.livePlaylist.Discontinuity()
+	}
+	if s.subtitles != nil {
+		s.subtitles.playlist.Discontinuity()
+		if s.subtitles.livePlaylist != nil {
+			s.subtitles.livePlaylist.Discontinuity()
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/pipeline/sink/uploader"
+	"github.com/livekit/egress/pkg/types"
+)
+
+type dataLine struct {
+	Type     config.DataRecordType `json:"type"`
+	Start    float64               `json:"start"` // seconds from the start of the recording
+	End      float64               `json:"end,omitempty"`
+	Identity string                `json:"identity,omitempty"`
+
+	Topic         string `json:"topic,omitempty"`
+	Payload       string `json:"payload,omitempty"`
+	PayloadBase64 []byte `json:"payload_base64,omitempty"` // non-utf8 payloads
+
+	TrackID  string `json:"track_id,omitempty"`
+	Language string `json:"language,omitempty"`
+	Text     string `json:"text,omitempty"`
+}
+
+// uploadDataCapture uploads {filepath}.data.jsonl for recorded data, and {filepath}.captions.vtt and {filepath}.captions.srt for captions
+func uploadDataCapture(p *config.PipelineConfig, u uploader.Uploader, localFilepath, storageFilepath string, offset time.Duration) ([]*Sidecar, error) {
+	var sidecars []*Sidecar
+	if p.DataCapture.Messages || p.DataCapture.Transcriptions {
+		dataSidecar, err := uploadDataRecords(p, u, localFilepath, storageFilepath, offset)
+		if err != nil {
+			return nil, err
+		}
+		sidecars = append(sidecars, dataSidecar)
+	}
+
+	if !p.DataRecorder.TranscriptionsEnabled() {
+		return sidecars, nil
+	}
+
+	vtt := &strings.Builder{}
+	vtt.WriteString(webVTTHeader)
+	srt := &strings.Builder{}
+	for i, transcription := range p.DataRecorder.Transcriptions() {
+		cue := &captionCue{
+			Start: transcription.Start + offset,
+			End:   transcription.End + offset,
+			Voice: transcription.Identity,
+			Text:  transcription.Text,
+		}
+		writeWebVTTCue(vtt, cue)
+		writeSRTCue(srt, i+1, cue)
+	}
+
+	vttSidecar, err := uploadSidecar(u, []byte(vtt.String()), localFilepath, storageFilepath, ".captions.vtt", types.OutputTypeVTT, "captions")
+	if err != nil {
+		return nil, err
+	}
+	srtSidecar, err := uploadSidecar(u, []byte(srt.String()), localFilepath, storageFilepath, ".captions.srt", types.OutputTypeSRTCaptions, "captions")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(sidecars, vttSidecar, srtSidecar), nil
+}
+
+func uploadDataRecords(p *config.PipelineConfig, u uploader.Uploader, localFilepath, storageFilepath string, offset time.Duration) (*Sidecar, error) {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	for _, record := range p.DataRecorder.Records() {
+		line := &dataLine{
+			Type:     record.Type,
+			Start:    (record.Start + offset).Seconds(),
+			Identity: record.Identity,
+			Topic:    record.Topic,
+			TrackID:  record.TrackID,
+			Language: record.Language,
+			Text:     record.Text,
+		}
+		if record.End > 0 {
+			line.End = (record.End + offset).Seconds()
+		}
+		if utf8.Valid(record.Payload) {
+			line.Payload = string(record.Payload)
+		} else {
+			line.PayloadBase64 = record.Payload
+		}
+		if err := encoder.Encode(line); err != nil {
+			return nil, err
+		}
+	}
+
+	return uploadSidecar(u, buf.Bytes(), localFilepath, storageFilepath, ".data.jsonl", types.OutputTypeJSONL, "data")
+}
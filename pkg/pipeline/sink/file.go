@@ -29,6 +29,8 @@ type FileSink struct {
 
 	conf *config.PipelineConfig
 	*config.FileConfig
+
+	clips chan *fileClips // intro and outro, encoded while recording
 }
 
 func newFileSink(u uploader.Uploader, conf *config.PipelineConfig, o *config.FileConfig) *FileSink {
@@ -40,10 +42,33 @@ func newFileSink(u uploader.Uploader, conf *config.PipelineConfig, o *config.Fil
 }
 
 func (s *FileSink) Start() error {
+	intro, outro, err := s.conf.GetClipURIs()
+	if err != nil {
+		return err
+	}
+	if intro != "" || outro != "" {
+		s.clips = make(chan *fileClips, 1)
+		go func() {
+			s.clips <- s.encodeClips(intro, outro)
+		}()
+	}
 	return nil
 }
 
 func (s *FileSink) Close() error {
+	if s.clips != nil {
+		clips := <-s.clips
+		err := clips.err
+		if err == nil {
+			err = s.addClips(clips)
+		}
+		clips.remove()
+		if err != nil {
+			// the recording is still uploaded
+			logger.Warnw("failed to add intro and outro", err)
+		}
+	}
+
 	location, size, err := s.Upload(s.LocalFilepath, s.StorageFilepath, s.OutputType, false, "file")
 	if err != nil {
 		return err
@@ -52,10 +77,15 @@ func (s *FileSink) Close() error {
 	s.FileInfo.Location = location
 	s.FileInfo.Size = size
 
+	sidecars, err := uploadSidecars(s.conf, s.Uploader, s.LocalFilepath, s.StorageFilepath, s.ContentOffset)
+	if err != nil {
+		return err
+	}
+
 	if !s.DisableManifest {
 		manifestLocalPath := fmt.Sprintf("%s.json", s.LocalFilepath)
 		manifestStoragePath := fmt.Sprintf("%s.json", s.StorageFilepath)
-		if err = uploadManifest(s.conf, s.Uploader, manifestLocalPath, manifestStoragePath); err != nil {
+		if err = uploadManifest(s.conf, s.Uploader, manifestLocalPath, manifestStoragePath, sidecars, s.ContentOffset); err != nil {
 			return err
 		}
 	}
@@ -26,7 +26,6 @@ import (
 	"github.com/livekit/egress/pkg/config"
 	"github.com/livekit/egress/pkg/gstreamer"
 	"github.com/livekit/egress/pkg/pipeline/sink/uploader"
-	"github.com/livekit/egress/pkg/types"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 )
@@ -46,6 +45,15 @@ type ImageSink struct {
 	manifest      *ImageManifest
 	createdImages chan *imageUpdate
 	done          core.Fuse
+
+	// sprite sheets
+	sprite      *spriteSheet
+	spriteCount int
+	tiles       []*spriteTile
+
+	// animated preview
+	previewFrames []string
+	previewDone   bool
 }
 
 type imageUpdate struct {
@@ -88,13 +96,19 @@ func (s *ImageSink) Start() error {
 }
 
 func (s *ImageSink) handleNewImage(update *imageUpdate) error {
-	s.ImagesInfo.ImageCount++
-
 	filename := update.filename
 	ts := s.getImageTime(update.timestamp)
 	imageLocalPath := path.Join(s.LocalDir, filename)
+	if s.Sprites != nil {
+		return s.addTile(imageLocalPath, ts)
+	}
+	if s.Preview != nil {
+		return s.addPreviewFrame(imageLocalPath)
+	}
+
+	s.ImagesInfo.ImageCount++
 	if s.ImageSuffix == livekit.ImageFileSuffix_IMAGE_SUFFIX_TIMESTAMP {
-		newFilename := fmt.Sprintf("%s_%s%03d%s", s.ImagePrefix, ts.Format("20060102150405"), ts.UnixMilli()%1000, types.FileExtensionForOutputType[s.OutputType])
+		newFilename := fmt.Sprintf("%s_%s%03d%s", s.ImagePrefix, ts.Format("20060102150405"), ts.UnixMilli()%1000, s.ImageExtension)
 		newImageLocalPath := path.Join(s.LocalDir, newFilename)
 
 		err := os.Rename(imageLocalPath, newImageLocalPath)
@@ -134,7 +148,10 @@ func (s *ImageSink) getImageTime(pts uint64) time.Time {
 
 func (s *ImageSink) updateManifest(filename string, ts time.Time, size int64) error {
 	s.manifest.imageCreated(filename, ts, size)
+	return s.uploadManifest()
+}
 
+func (s *ImageSink) uploadManifest() error {
 	manifestLocalPath := fmt.Sprintf("%s.json", path.Join(s.LocalDir, s.ImagePrefix))
 	manifestStoragePath := fmt.Sprintf("%s.json", path.Join(s.StorageDir, s.ImagePrefix))
 	return s.manifest.updateManifest(s.Uploader, manifestLocalPath, manifestStoragePath)
@@ -159,6 +176,12 @@ func (s *ImageSink) Close() error {
 	close(s.createdImages)
 	<-s.done.Watch()
 
+	if s.sprite != nil {
+		return s.uploadSprite()
+	}
+	if s.Preview != nil && !s.previewDone {
+		return s.uploadPreview()
+	}
 	return nil
 }
 
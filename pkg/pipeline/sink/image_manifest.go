@@ -27,7 +27,9 @@ import (
 type ImageManifest struct {
 	Manifest `json:",inline"`
 
-	Images []*Image `json:"images"`
+	Images     []*Image  `json:"images"`
+	Sprites    []*Sprite `json:"sprites,omitempty"`
+	Thumbnails string    `json:"thumbnails,omitempty"` // webvtt track referencing sprite tiles
 }
 
 type Image struct {
@@ -36,6 +38,13 @@ type Image struct {
 	Size      int64     `json:"size"`
 }
 
+type Sprite struct {
+	Name      string    `json:"name"`
+	Timestamp time.Time `json:"timestamp"` // first tile
+	Size      int64     `json:"size"`
+	Tiles     int       `json:"tiles"`
+}
+
 func createImageManifest(p *config.PipelineConfig) *ImageManifest {
 	return &ImageManifest{
 		Manifest: initManifest(p),
@@ -50,6 +59,16 @@ func (m *ImageManifest) imageCreated(filename string, ts time.Time, size int64)
 	})
 }
 
+func (m *ImageManifest) spriteCreated(filename string, ts time.Time, size int64, tiles int, thumbnails string) {
+	m.Sprites = append(m.Sprites, &Sprite{
+		Name:      filename,
+		Timestamp: ts,
+		Size:      size,
+		Tiles:     tiles,
+	})
+	m.Thumbnails = thumbnails
+}
+
 func (m *ImageManifest) updateManifest(u uploader.Uploader, localFilepath, storageFilepath string) error {
 	manifest, err := os.Create(localFilepath)
 	if err != nil {
@@ -0,0 +1,78 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package m3u8
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Fragment is a fragmented mp4 segment, with its init section (ftyp and moov) ahead of the media fragments
+type Fragment struct {
+	InitSize int64
+	Size     int64
+}
+
+// ReadFragment finds the init section of an fmp4 segment by walking its top level boxes up to the first moof
+func ReadFragment(filename string) (*Fragment, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	fragment := &Fragment{Size: info.Size()}
+	header := make([]byte, 16)
+	for offset := int64(0); offset < fragment.Size; {
+		if _, err = f.ReadAt(header[:8], offset); err != nil {
+			return nil, err
+		}
+
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		boxType := string(header[4:8])
+		switch boxSize {
+		case 0:
+			// box extends to the end of the file
+			boxSize = fragment.Size - offset
+		case 1:
+			if _, err = f.ReadAt(header[8:], offset+8); err != nil {
+				return nil, err
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:]))
+		}
+
+		if boxType == "moof" {
+			if offset == 0 {
+				return nil, errors.New("fragment has no init section")
+			}
+			fragment.InitSize = offset
+			return fragment, nil
+		}
+		if boxSize < 8 {
+			return nil, fmt.Errorf("invalid %s box size %d", boxType, boxSize)
+		}
+		offset += boxSize
+	}
+
+	return nil, io.ErrUnexpectedEOF
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package m3u8
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const subtitlesGroupID = "subs"
+
+type MasterPlaylist struct {
+	Playlist   string // media playlist
+	Bandwidth  int    // peak bits per second
+	Resolution string // optional, e.g. 1920x1080
+
+	// optional subtitles rendition
+	Subtitles         string
+	SubtitlesName     string
+	SubtitlesLanguage string
+}
+
+func (m *MasterPlaylist) String() string {
+	var sb strings.Builder
+	sb.WriteString("#EXTM3U\n")
+	sb.WriteString("#EXT-X-VERSION:4\n")
+	if m.Subtitles != "" {
+		sb.WriteString(fmt.Sprintf(
+			"#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID=%q,NAME=%q,DEFAULT=YES,AUTOSELECT=YES,",
+			subtitlesGroupID, m.SubtitlesName,
+		))
+		if m.SubtitlesLanguage != "" {
+			sb.WriteString(fmt.Sprintf("LANGUAGE=%q,", m.SubtitlesLanguage))
+		}
+		sb.WriteString(fmt.Sprintf("URI=%q\n", m.Subtitles))
+	}
+
+	sb.WriteString(fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d", m.Bandwidth))
+	if m.Resolution != "" {
+		sb.WriteString(fmt.Sprintf(",RESOLUTION=%s", m.Resolution))
+	}
+	if m.Subtitles != "" {
+		sb.WriteString(fmt.Sprintf(",SUBTITLES=%q", subtitlesGroupID))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(m.Playlist)
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+func WriteMasterPlaylist(filename string, m *MasterPlaylist) error {
+	return os.WriteFile(filename, []byte(m.String()), 0644)
+}
@@ -31,14 +31,22 @@ const (
 	PlaylistTypeEvent PlaylistType = "EVENT"
 )
 
+const discontinuityTag = "#EXT-X-DISCONTINUITY\n"
+
 type PlaylistWriter interface {
 	Append(dateTime time.Time, duration float64, filename string) error
+	// AppendFragment adds a fragmented mp4 segment, which carries its own init section
+	AppendFragment(dateTime time.Time, duration float64, filename string, fragment *Fragment) error
+	// Discontinuity marks the next segment as having different timestamps or encoding
+	Discontinuity()
 	Close() error
 }
 
 type basePlaylistWriter struct {
 	filename       string
 	targetDuration int
+	fragmented     bool
+	discontinuity  bool
 }
 
 type eventPlaylistWriter struct {
@@ -48,8 +56,9 @@ type eventPlaylistWriter struct {
 type livePlaylistWriter struct {
 	basePlaylistWriter
 
-	windowSize int
-	mediaSeq   int
+	windowSize       int
+	mediaSeq         int
+	discontinuitySeq int
 
 	livePlaylistHeader   string
 	livePlaylistSegments *list.List
@@ -58,7 +67,12 @@ type livePlaylistWriter struct {
 func (p *basePlaylistWriter) createHeader(plType PlaylistType) string {
 	var sb strings.Builder
 	sb.WriteString("#EXTM3U\n")
-	sb.WriteString("#EXT-X-VERSION:4\n")
+	if p.fragmented {
+		// required for EXT-X-MAP
+		sb.WriteString("#EXT-X-VERSION:6\n")
+	} else {
+		sb.WriteString("#EXT-X-VERSION:4\n")
+	}
 	if plType != PlaylistTypeLive {
 		sb.WriteString(fmt.Sprintf("#EXT-X-PLAYLIST-TYPE:%s\n", plType))
 	}
@@ -71,25 +85,40 @@ func (p *basePlaylistWriter) createHeader(plType PlaylistType) string {
 	return sb.String()
 }
 
-func (p *basePlaylistWriter) createSegmentEntry(dateTime time.Time, duration float64, filename string) string {
+func (p *basePlaylistWriter) Discontinuity() {
+	p.discontinuity = true
+}
+
+func (p *basePlaylistWriter) createSegmentEntry(dateTime time.Time, duration float64, filename string, fragment *Fragment) string {
 	var sb strings.Builder
 
+	if p.discontinuity {
+		sb.WriteString(discontinuityTag)
+		p.discontinuity = false
+	}
+	if fragment != nil {
+		sb.WriteString(fmt.Sprintf("#EXT-X-MAP:URI=\"%s\",BYTERANGE=\"%d@0\"\n", filename, fragment.InitSize))
+	}
 	sb.WriteString("#EXT-X-PROGRAM-DATE-TIME:")
 	sb.WriteString(dateTime.UTC().Format("2006-01-02T15:04:05.999Z07:00"))
 	sb.WriteString("\n#EXTINF:")
 	sb.WriteString(strconv.FormatFloat(duration, 'f', 3, 32))
 	sb.WriteString(",\n")
+	if fragment != nil {
+		sb.WriteString(fmt.Sprintf("#EXT-X-BYTERANGE:%d@%d\n", fragment.Size-fragment.InitSize, fragment.InitSize))
+	}
 	sb.WriteString(filename)
 	sb.WriteString("\n")
 
 	return sb.String()
 }
 
-func NewEventPlaylistWriter(filename string, targetDuration int) (PlaylistWriter, error) {
+func NewEventPlaylistWriter(filename string, targetDuration int, fragmented bool) (PlaylistWriter, error) {
 	p := &eventPlaylistWriter{
 		basePlaylistWriter: basePlaylistWriter{
 			filename:       filename,
 			targetDuration: targetDuration,
+			fragmented:     fragmented,
 		},
 	}
 
@@ -108,13 +137,21 @@ func NewEventPlaylistWriter(filename string, targetDuration int) (PlaylistWriter
 }
 
 func (p *eventPlaylistWriter) Append(dateTime time.Time, duration float64, filename string) error {
+	return p.append(dateTime, duration, filename, nil)
+}
+
+func (p *eventPlaylistWriter) AppendFragment(dateTime time.Time, duration float64, filename string, fragment *Fragment) error {
+	return p.append(dateTime, duration, filename, fragment)
+}
+
+func (p *eventPlaylistWriter) append(dateTime time.Time, duration float64, filename string, fragment *Fragment) error {
 	f, err := os.OpenFile(p.filename, os.O_WRONLY|os.O_APPEND, fs.ModeAppend)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	_, err = f.WriteString(p.createSegmentEntry(dateTime, duration, filename))
+	_, err = f.WriteString(p.createSegmentEntry(dateTime, duration, filename, fragment))
 	return err
 }
 
@@ -130,11 +167,12 @@ func (p *eventPlaylistWriter) Close() error {
 	return err
 }
 
-func NewLivePlaylistWriter(filename string, targetDuration int, windowSize int) (PlaylistWriter, error) {
+func NewLivePlaylistWriter(filename string, targetDuration int, windowSize int, fragmented bool) (PlaylistWriter, error) {
 	p := &livePlaylistWriter{
 		basePlaylistWriter: basePlaylistWriter{
 			filename:       filename,
 			targetDuration: targetDuration,
+			fragmented:     fragmented,
 		},
 		windowSize:           windowSize,
 		livePlaylistSegments: list.New(),
@@ -146,18 +184,29 @@ func NewLivePlaylistWriter(filename string, targetDuration int, windowSize int)
 }
 
 func (p *livePlaylistWriter) Append(dateTime time.Time, duration float64, filename string) error {
+	return p.append(dateTime, duration, filename, nil)
+}
+
+func (p *livePlaylistWriter) AppendFragment(dateTime time.Time, duration float64, filename string, fragment *Fragment) error {
+	return p.append(dateTime, duration, filename, fragment)
+}
+
+func (p *livePlaylistWriter) append(dateTime time.Time, duration float64, filename string, fragment *Fragment) error {
 	f, err := os.Create(p.filename)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	segmentStr := p.createSegmentEntry(dateTime, duration, filename)
+	segmentStr := p.createSegmentEntry(dateTime, duration, filename, fragment)
 	p.livePlaylistSegments.PushBack(segmentStr)
 
 	for p.livePlaylistSegments.Len() > p.windowSize {
-		p.livePlaylistSegments.Remove(p.livePlaylistSegments.Front())
+		removed := p.livePlaylistSegments.Remove(p.livePlaylistSegments.Front()).(string)
 		p.mediaSeq++
+		if strings.HasPrefix(removed, discontinuityTag) {
+			p.discontinuitySeq++
+		}
 	}
 
 	_, err = f.WriteString(p.generatePlaylist())
@@ -184,6 +233,9 @@ func (p *livePlaylistWriter) generatePlaylist() string {
 	var sb strings.Builder
 	sb.WriteString(p.livePlaylistHeader)
 	sb.WriteString(fmt.Sprintf("#EXT-X-MEDIA-SEQUENCE:%d\n", p.mediaSeq))
+	if p.discontinuitySeq > 0 {
+		sb.WriteString(fmt.Sprintf("#EXT-X-DISCONTINUITY-SEQUENCE:%d\n", p.discontinuitySeq))
+	}
 	for elem := p.livePlaylistSegments.Front(); elem != nil; elem = elem.Next() {
 		segmentStr := elem.Value.(string)
 		sb.WriteString(segmentStr)
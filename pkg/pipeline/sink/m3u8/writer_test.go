@@ -15,6 +15,7 @@
 package m3u8
 
 import (
+	"encoding/binary"
 	"fmt"
 	"os"
 	"testing"
@@ -26,7 +27,7 @@ import (
 func TestEventPlaylistWriter(t *testing.T) {
 	playlistName := "playlist.m3u8"
 
-	w, err := NewEventPlaylistWriter(playlistName, 6)
+	w, err := NewEventPlaylistWriter(playlistName, 6, false)
 	require.NoError(t, err)
 
 	t.Cleanup(func() { _ = os.Remove(playlistName) })
@@ -51,7 +52,7 @@ func TestEventPlaylistWriter(t *testing.T) {
 func TestLivePlaylistWriter(t *testing.T) {
 	playlistName := "playlist.m3u8"
 
-	w, err := NewLivePlaylistWriter(playlistName, 6, 3)
+	w, err := NewLivePlaylistWriter(playlistName, 6, 3, false)
 	require.NoError(t, err)
 
 	t.Cleanup(func() { _ = os.Remove(playlistName) })
@@ -80,6 +81,89 @@ func TestLivePlaylistWriter(t *testing.T) {
 	b, err = os.ReadFile(playlistName)
 	require.NoError(t, err)
 
-	expected = "#EXTM3U\n#EXT-X-VERSION:4\n#EXT-X-ALLOW-CACHE:NO\n#EXT-X-TARGETDURATION:6\n#EXT-X-MEDIA-SEQUENCE:1\n#EXT-X-PROGRAM-DATE-TIME:2023-05-03T22:55:04.814Z\n#EXTINF:5.994,\nplaylist_00001.ts\n#EXT-X-PROGRAM-DATE-TIME:2023-05-03T22:55:16.802Z\n#EXTINF:5.994,\nplaylist_00002.ts\n#EXT-X-PROGRAM-DATE-TIME:2023-05-03T22:55:22.796Z\n#EXTINF:5.994,\nplaylist_00003.ts\n#EXT-X-ENDLIST\n"
+	expected = "#EXTM3U\n#EXT-X-VERSION:4\n#EXT-X-ALLOW-CACHE:NO\n#EXT-X-TARGETDURATION:6\n#EXT-X-MEDIA-SEQUENCE:1\n#EXT-X-PROGRAM-DATE-TIME:2023-05-03T22:55:10.808Z\n#EXTINF:5.994,\nplaylist_00001.ts\n#EXT-X-PROGRAM-DATE-TIME:2023-05-03T22:55:16.802Z\n#EXTINF:5.994,\nplaylist_00002.ts\n#EXT-X-PROGRAM-DATE-TIME:2023-05-03T22:55:22.796Z\n#EXTINF:5.994,\nplaylist_00003.ts\n#EXT-X-ENDLIST\n"
 	require.Equal(t, expected, string(b))
 }
+
+func TestDiscontinuity(t *testing.T) {
+	playlistName := "playlist.m3u8"
+
+	w, err := NewLivePlaylistWriter(playlistName, 6, 2, false)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = os.Remove(playlistName) })
+
+	now := time.Unix(0, 1683154504814142000)
+	duration := 5.994
+
+	require.NoError(t, w.Append(now, duration, "playlist_intro_00000.ts"))
+	w.Discontinuity()
+	for i := 0; i < 3; i++ {
+		now = now.Add(time.Millisecond * 5994)
+		require.NoError(t, w.Append(now, duration, fmt.Sprintf("playlist_0000%d.ts", i)))
+	}
+
+	b, err := os.ReadFile(playlistName)
+	require.NoError(t, err)
+
+	expected := "#EXTM3U\n#EXT-X-VERSION:4\n#EXT-X-ALLOW-CACHE:NO\n#EXT-X-TARGETDURATION:6\n#EXT-X-MEDIA-SEQUENCE:2\n#EXT-X-DISCONTINUITY-SEQUENCE:1\n#EXT-X-PROGRAM-DATE-TIME:2023-05-03T22:55:16.802Z\n#EXTINF:5.994,\nplaylist_00001.ts\n#EXT-X-PROGRAM-DATE-TIME:2023-05-03T22:55:22.796Z\n#EXTINF:5.994,\nplaylist_00002.ts\n"
+	require.Equal(t, expected, string(b))
+}
+
+func TestFragmentedPlaylist(t *testing.T) {
+	playlistName := "playlist.m3u8"
+	fragmentName := "playlist_00000.m4s"
+
+	t.Cleanup(func() {
+		_ = os.Remove(playlistName)
+		_ = os.Remove(fragmentName)
+	})
+
+	box := func(boxType string, size int) []byte {
+		b := make([]byte, size)
+		binary.BigEndian.PutUint32(b, uint32(size))
+		copy(b[4:], boxType)
+		return b
+	}
+	var data []byte
+	for _, b := range [][]byte{box("ftyp", 24), box("moov", 700), box("moof", 100), box("mdat", 5000)} {
+		data = append(data, b...)
+	}
+	require.NoError(t, os.WriteFile(fragmentName, data, 0644))
+
+	fragment, err := ReadFragment(fragmentName)
+	require.NoError(t, err)
+	require.Equal(t, &Fragment{InitSize: 724, Size: 5824}, fragment)
+
+	w, err := NewEventPlaylistWriter(playlistName, 6, true)
+	require.NoError(t, err)
+
+	now := time.Unix(0, 1683154504814142000)
+	require.NoError(t, w.AppendFragment(now, 5.994, fragmentName, fragment))
+	require.NoError(t, w.Close())
+
+	b, err := os.ReadFile(playlistName)
+	require.NoError(t, err)
+
+	expected := "#EXTM3U\n#EXT-X-VERSION:6\n#EXT-X-PLAYLIST-TYPE:EVENT\n#EXT-X-ALLOW-CACHE:NO\n#EXT-X-TARGETDURATION:6\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-MAP:URI=\"playlist_00000.m4s\",BYTERANGE=\"724@0\"\n#EXT-X-PROGRAM-DATE-TIME:2023-05-03T22:55:04.814Z\n#EXTINF:5.994,\n#EXT-X-BYTERANGE:5100@724\nplaylist_00000.m4s\n#EXT-X-ENDLIST\n"
+	require.Equal(t, expected, string(b))
+
+	// a segment without a moof is not fragmented
+	require.NoError(t, os.WriteFile(fragmentName, box("ftyp", 24), 0644))
+	_, err = ReadFragment(fragmentName)
+	require.Error(t, err)
+}
+
+func TestMasterPlaylist(t *testing.T) {
+	m := &MasterPlaylist{
+		Playlist:          "playlist.m3u8",
+		Bandwidth:         3128000,
+		Resolution:        "1920x1080",
+		Subtitles:         "playlist-subtitles.m3u8",
+		SubtitlesName:     "Captions",
+		SubtitlesLanguage: "en",
+	}
+
+	expected := "#EXTM3U\n#EXT-X-VERSION:4\n#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID=\"subs\",NAME=\"Captions\",DEFAULT=YES,AUTOSELECT=YES,LANGUAGE=\"en\",URI=\"playlist-subtitles.m3u8\"\n#EXT-X-STREAM-INF:BANDWIDTH=3128000,RESOLUTION=1920x1080,SUBTITLES=\"subs\"\nplaylist.m3u8\n"
+	require.Equal(t, expected, m.String())
+}
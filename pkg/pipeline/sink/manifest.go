@@ -16,7 +16,10 @@ package sink
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
 	"os"
+	"time"
 
 	"github.com/livekit/egress/pkg/config"
 	"github.com/livekit/egress/pkg/pipeline/sink/uploader"
@@ -37,15 +40,38 @@ type Manifest struct {
 	AudioTrackID      string `json:"audio_track_id,omitempty"`
 	VideoTrackID      string `json:"video_track_id,omitempty"`
 	SegmentCount      int64  `json:"segment_count,omitempty"`
+
+	// seconds of intro before the live content, already included in the offsets below
+	ContentOffset float64 `json:"content_offset,omitempty"`
+
+	IntegratedLoudness *float64            `json:"integrated_loudness,omitempty"`
+	SpeakingTimeline   []*SpeakingInterval `json:"speaking_timeline,omitempty"`
+	VideoLayers        []*VideoLayerSwitch `json:"video_layers,omitempty"`
+	Sidecars           []*Sidecar          `json:"sidecars,omitempty"`
+}
+
+type VideoLayerSwitch struct {
+	TrackID   string  `json:"track_id"`
+	Quality   string  `json:"quality,omitempty"`
+	Requested string  `json:"requested,omitempty"`
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	Offset    float64 `json:"offset"` // seconds from the start of the recording
+}
+
+type Sidecar struct {
+	Type     string `json:"type"`
+	Location string `json:"location"`
 }
 
-func uploadManifest(p *config.PipelineConfig, u uploader.Uploader, localFilepath, storageFilepath string) error {
+// uploadManifest uploads the manifest of an output, whose timeline is shifted by its intro
+func uploadManifest(p *config.PipelineConfig, u uploader.Uploader, localFilepath, storageFilepath string, sidecars []*Sidecar, offset time.Duration) error {
 	manifest, err := os.Create(localFilepath)
 	if err != nil {
 		return err
 	}
 
-	b, err := getManifest(p)
+	b, err := getManifest(p, sidecars, offset)
 	if err != nil {
 		return err
 	}
@@ -60,16 +86,46 @@ func uploadManifest(p *config.PipelineConfig, u uploader.Uploader, localFilepath
 	return err
 }
 
-func getManifest(p *config.PipelineConfig) ([]byte, error) {
+func getManifest(p *config.PipelineConfig, sidecars []*Sidecar, offset time.Duration) ([]byte, error) {
 	manifest := initManifest(p)
+	manifest.Sidecars = sidecars
+	manifest.ContentOffset = offset.Seconds()
 
 	if o := p.GetSegmentConfig(); o != nil {
 		manifest.SegmentCount = o.SegmentsInfo.SegmentCount
 	}
+	if p.Speakers != nil {
+		manifest.SpeakingTimeline = getSpeakingTimeline(p, offset)
+	}
+	if p.VideoLayers != nil {
+		manifest.VideoLayers = getVideoLayers(p)
+	}
+	if p.AudioEnabled && p.AudioProcessing.Enabled() {
+		// silence measures as -inf, which can't be encoded
+		if l := p.IntegratedLoudness.Load(); !math.IsInf(l, 0) && !math.IsNaN(l) && l != 0 {
+			manifest.IntegratedLoudness = &l
+		}
+	}
 
 	return json.Marshal(manifest)
 }
 
+func getVideoLayers(p *config.PipelineConfig) []*VideoLayerSwitch {
+	switches := p.VideoLayers.Switches()
+	layers := make([]*VideoLayerSwitch, 0, len(switches))
+	for _, s := range switches {
+		layers = append(layers, &VideoLayerSwitch{
+			TrackID:   s.TrackID,
+			Quality:   s.Quality,
+			Requested: s.Requested,
+			Width:     s.Width,
+			Height:    s.Height,
+			Offset:    s.Offset.Seconds(),
+		})
+	}
+	return layers
+}
+
 func initManifest(p *config.PipelineConfig) Manifest {
 	return Manifest{
 		EgressID:          p.Info.EgressId,
@@ -86,3 +142,48 @@ func initManifest(p *config.PipelineConfig) Manifest {
 		VideoTrackID:      p.VideoTrackID,
 	}
 }
+
+// uploadSidecars uploads any files recorded alongside the output, with times shifted by its intro
+func uploadSidecars(p *config.PipelineConfig, u uploader.Uploader, localFilepath, storageFilepath string, offset time.Duration) ([]*Sidecar, error) {
+	var sidecars []*Sidecar
+	if p.Speakers != nil {
+		speakers, err := uploadSpeakingTimeline(p, u, localFilepath, storageFilepath, offset)
+		if err != nil {
+			return nil, err
+		}
+		sidecars = append(sidecars, speakers...)
+	}
+	if p.DataRecorder != nil {
+		data, err := uploadDataCapture(p, u, localFilepath, storageFilepath, offset)
+		if err != nil {
+			return nil, err
+		}
+		sidecars = append(sidecars, data...)
+	}
+	if p.Quality != nil {
+		quality, err := uploadQualityReport(p, u, localFilepath, storageFilepath)
+		if err != nil {
+			return nil, err
+		}
+		sidecars = append(sidecars, quality)
+	}
+	return sidecars, nil
+}
+
+// uploadSidecar uploads b as {filepath}{suffix}
+func uploadSidecar(u uploader.Uploader, b []byte, localFilepath, storageFilepath, suffix string, outputType types.OutputType, fileType string) (*Sidecar, error) {
+	localPath := fmt.Sprintf("%s%s", localFilepath, suffix)
+	if err := os.WriteFile(localPath, b, 0644); err != nil {
+		return nil, err
+	}
+
+	location, _, err := u.Upload(localPath, fmt.Sprintf("%s%s", storageFilepath, suffix), outputType, false, fileType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sidecar{
+		Type:     fileType,
+		Location: location,
+	}, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	_ "image/jpeg"
+	"os"
+	"path"
+	"time"
+
+	"github.com/go-gst/go-gst/gst"
+	"github.com/go-gst/go-gst/gst/app"
+
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/types"
+)
+
+const previewEncodeTimeout = time.Second * 30
+
+func (s *ImageSink) addPreviewFrame(localPath string) error {
+	if s.previewDone {
+		return os.Remove(localPath)
+	}
+
+	s.previewFrames = append(s.previewFrames, localPath)
+	if int32(len(s.previewFrames)) >= s.Preview.GetPreviewFrameCount() {
+		return s.uploadPreview()
+	}
+	return nil
+}
+
+// uploadPreview combines the captured frames into a looping animation
+func (s *ImageSink) uploadPreview() error {
+	s.previewDone = true
+	if len(s.previewFrames) == 0 {
+		return nil
+	}
+
+	filename := fmt.Sprintf("%s%s", s.ImagePrefix, types.FileExtensionForOutputType[s.OutputType])
+	localPath := path.Join(s.LocalDir, filename)
+
+	var err error
+	switch s.OutputType {
+	case types.OutputTypeGIF:
+		err = s.encodeGIF(localPath)
+	case types.OutputTypeWebP:
+		err = s.encodeAnimatedWebP(localPath)
+	default:
+		err = errors.ErrNoCompatibleCodec
+	}
+	for _, frame := range s.previewFrames {
+		_ = os.Remove(frame)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, size, err := s.Upload(localPath, path.Join(s.StorageDir, filename), s.OutputType, true, "preview")
+	if err != nil {
+		return err
+	}
+	s.ImagesInfo.ImageCount++
+
+	if !s.DisableManifest {
+		return s.updateManifest(filename, s.startTime, size)
+	}
+	return nil
+}
+
+func (s *ImageSink) encodeGIF(localPath string) error {
+	anim := &gif.GIF{}
+	delay := int(100 / s.Preview.Framerate)
+	for _, frame := range s.previewFrames {
+		f, err := os.Open(frame)
+		if err != nil {
+			return err
+		}
+		img, _, err := image.Decode(f)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+
+		paletted := image.NewPaletted(img.Bounds(), palette.Plan9)
+		draw.FloydSteinberg.Draw(paletted, img.Bounds(), img, img.Bounds().Min)
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	f, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return gif.EncodeAll(f, anim)
+}
+
+// encodeAnimatedWebP runs a short pipeline over the frames. Frame paths and the output location are named after the
+// request's filename prefix, so frames are pushed through an appsrc and the location is only set as a property
+func (s *ImageSink) encodeAnimatedWebP(localPath string) error {
+	pipeline, err := gst.NewPipeline("preview")
+	if err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	src, err := app.NewAppSrc()
+	if err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	src.Element.SetArg("format", "time")
+	src.SetCaps(gst.NewCapsFromString(fmt.Sprintf("image/jpeg,framerate=%d/1", s.Preview.Framerate)))
+
+	elements, err := gst.NewElementMany("jpegdec", "videoconvert", "webpenc", "filesink")
+	if err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	enc, sink := elements[2], elements[3]
+	enc.SetArg("animated", "true")
+	enc.SetArg("animation-loops", "0")
+	if err = sink.SetProperty("location", localPath); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+
+	elements = append([]*gst.Element{src.Element}, elements...)
+	if err = pipeline.AddMany(elements...); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	if err = gst.ElementLinkMany(elements...); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+	defer func() {
+		_ = pipeline.SetState(gst.StateNull)
+	}()
+
+	if err = pipeline.SetState(gst.StatePlaying); err != nil {
+		return errors.ErrGstPipelineError(err)
+	}
+
+	frameDuration := time.Second / time.Duration(s.Preview.Framerate)
+	for i, frame := range s.previewFrames {
+		data, err := os.ReadFile(frame)
+		if err != nil {
+			return err
+		}
+		buffer := gst.NewBufferFromBytes(data)
+		buffer.SetPresentationTimestamp(gst.ClockTime(uint64(frameDuration) * uint64(i)))
+		buffer.SetDuration(gst.ClockTime(uint64(frameDuration)))
+		if flow := src.PushBuffer(buffer); flow != gst.FlowOK {
+			return errors.ErrGstPipelineError(errors.New(flow.String()))
+		}
+	}
+	src.EndStream()
+
+	msg := pipeline.GetPipelineBus().TimedPopFiltered(gst.ClockTime(uint64(previewEncodeTimeout)), gst.MessageEOS|gst.MessageError)
+	switch {
+	case msg == nil:
+		return errors.ErrGstPipelineError(errors.New("preview encoding timed out"))
+	case msg.Type() == gst.MessageError:
+		return errors.ErrGstPipelineError(msg.ParseError())
+	default:
+		return nil
+	}
+}
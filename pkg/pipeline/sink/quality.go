@@ -0,0 +1,63 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"encoding/json"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/pipeline/sink/uploader"
+	"github.com/livekit/egress/pkg/types"
+)
+
+type QualityReport struct {
+	EgressID string          `json:"egress_id,omitempty"`
+	Summary  string          `json:"summary,omitempty"`
+	Tracks   []*TrackQuality `json:"tracks"`
+}
+
+type TrackQuality struct {
+	TrackID    string  `json:"track_id"`
+	Kind       string  `json:"kind"`
+	Identity   string  `json:"identity,omitempty"`
+	AvgDriftMs float64 `json:"avg_drift_ms"`
+	MaxDriftMs float64 `json:"max_drift_ms"`
+	PacketLoss float64 `json:"packet_loss"` // fraction of packets dropped
+}
+
+// uploadQualityReport uploads per-track a/v sync and packet loss stats as {filepath}.quality.json
+func uploadQualityReport(p *config.PipelineConfig, u uploader.Uploader, localFilepath, storageFilepath string) (*Sidecar, error) {
+	report := &QualityReport{
+		EgressID: p.Info.EgressId,
+		Summary:  p.Quality.Summary(),
+		Tracks:   make([]*TrackQuality, 0),
+	}
+	for _, t := range p.Quality.Tracks() {
+		report.Tracks = append(report.Tracks, &TrackQuality{
+			TrackID:    t.TrackID,
+			Kind:       t.Kind,
+			Identity:   t.Identity,
+			AvgDriftMs: float64(t.AvgDrift.Microseconds()) / 1000,
+			MaxDriftMs: float64(t.MaxDrift.Microseconds()) / 1000,
+			PacketLoss: t.PacketLoss,
+		})
+	}
+
+	b, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	return uploadSidecar(u, b, localFilepath, storageFilepath, ".quality.json", types.OutputTypeJSON, "quality")
+}
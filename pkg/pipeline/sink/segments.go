@@ -48,6 +48,7 @@ type SegmentSink struct {
 
 	playlist     m3u8.PlaylistWriter
 	livePlaylist m3u8.PlaylistWriter
+	subtitles    *subtitleRendition
 
 	segmentLock  sync.Mutex
 	infoLock     sync.Mutex
@@ -58,6 +59,13 @@ type SegmentSink struct {
 	outputType            types.OutputType
 	startRunningTime      uint64
 	openSegmentsStartTime map[string]uint64
+	lastSegmentEnd        time.Time
+
+	intro         []*clipSegment
+	outro         []*clipSegment
+	introReady    chan struct{} // closed once the intro is encoded
+	outroReady    chan struct{} // closed once the outro is encoded
+	introAppended bool
 
 	closedSegments  chan SegmentUpdate
 	playlistUpdates chan SegmentUpdate
@@ -67,12 +75,19 @@ type SegmentSink struct {
 type SegmentUpdate struct {
 	endTime        uint64
 	filename       string
+	fragment       *m3u8.Fragment // set for fmp4 segments
 	uploadComplete chan struct{}
 }
 
 func newSegmentSink(u uploader.Uploader, p *config.PipelineConfig, o *config.SegmentConfig, callbacks *gstreamer.Callbacks, monitor *stats.HandlerMonitor) (*SegmentSink, error) {
+	outputType := o.OutputType
+	if outputType == types.OutputTypeHLS {
+		outputType = p.GetSegmentOutputType()
+	}
+	fragmented := outputType == types.OutputTypeM4S
+
 	playlistName := path.Join(o.LocalDir, o.PlaylistFilename)
-	playlist, err := m3u8.NewEventPlaylistWriter(playlistName, o.SegmentDuration)
+	playlist, err := m3u8.NewEventPlaylistWriter(playlistName, o.SegmentDuration, fragmented)
 	if err != nil {
 		return nil, err
 	}
@@ -80,15 +95,18 @@ func newSegmentSink(u uploader.Uploader, p *config.PipelineConfig, o *config.Seg
 	var livePlaylist m3u8.PlaylistWriter
 	if o.LivePlaylistFilename != "" {
 		playlistName = path.Join(o.LocalDir, o.LivePlaylistFilename)
-		livePlaylist, err = m3u8.NewLivePlaylistWriter(playlistName, o.SegmentDuration, defaultLivePlaylistWindow)
+		livePlaylist, err = m3u8.NewLivePlaylistWriter(playlistName, o.SegmentDuration, defaultLivePlaylistWindow, fragmented)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	outputType := o.OutputType
-	if outputType == types.OutputTypeHLS {
-		outputType = types.OutputTypeTS
+	var subtitles *subtitleRendition
+	if p.DataRecorder != nil && p.DataRecorder.TranscriptionsEnabled() {
+		subtitles, err = newSubtitleRendition(p, o)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	s := &SegmentSink{
@@ -98,6 +116,7 @@ func newSegmentSink(u uploader.Uploader, p *config.PipelineConfig, o *config.Seg
 		callbacks:             callbacks,
 		playlist:              playlist,
 		livePlaylist:          livePlaylist,
+		subtitles:             subtitles,
 		outputType:            outputType,
 		openSegmentsStartTime: make(map[string]uint64),
 		closedSegments:        make(chan SegmentUpdate, maxPendingUploads),
@@ -118,6 +137,17 @@ func newSegmentSink(u uploader.Uploader, p *config.PipelineConfig, o *config.Seg
 }
 
 func (s *SegmentSink) Start() error {
+	intro, outro, err := s.conf.GetClipURIs()
+	if err != nil {
+		return err
+	}
+
+	if s.subtitles != nil {
+		if err = s.uploadMasterPlaylists(); err != nil {
+			return err
+		}
+	}
+
 	go func() {
 		defer close(s.playlistUpdates)
 		for update := range s.closedSegments {
@@ -125,6 +155,16 @@ func (s *SegmentSink) Start() error {
 		}
 	}()
 
+	if intro != "" {
+		s.introReady = make(chan struct{})
+	}
+	if outro != "" {
+		s.outroReady = make(chan struct{})
+	}
+	if intro != "" || outro != "" {
+		go s.encodeClips(intro, outro)
+	}
+
 	go func() {
 		defer s.done.Break()
 		for update := range s.playlistUpdates {
@@ -139,28 +179,45 @@ func (s *SegmentSink) Start() error {
 }
 
 func (s *SegmentSink) handleClosedSegment(update SegmentUpdate) {
-	// keep playlist updates in order
-	s.playlistUpdates <- update
-
 	segmentLocalPath := path.Join(s.LocalDir, update.filename)
 	segmentStoragePath := path.Join(s.StorageDir, update.filename)
 
-	// upload in parallel
-	go func() {
-		defer close(update.uploadComplete)
-
-		_, size, err := s.Upload(segmentLocalPath, segmentStoragePath, s.outputType, true, "segment")
+	if s.outputType == types.OutputTypeM4S {
+		// the playlist needs the init section size, which must be read before the upload removes the segment
+		fragment, err := m3u8.ReadFragment(segmentLocalPath)
 		if err != nil {
+			// without its init section, the segment is uploaded but can't be added to the playlist
 			s.callbacks.OnError(err)
+			s.segmentLock.Lock()
+			delete(s.openSegmentsStartTime, update.filename)
+			s.segmentLock.Unlock()
+			go s.uploadSegment(update, segmentLocalPath, segmentStoragePath)
 			return
 		}
+		update.fragment = fragment
+	}
 
-		// lock segment info updates
-		s.infoLock.Lock()
-		s.SegmentsInfo.SegmentCount++
-		s.SegmentsInfo.Size += size
-		s.infoLock.Unlock()
-	}()
+	// keep playlist updates in order
+	s.playlistUpdates <- update
+
+	// upload in parallel
+	go s.uploadSegment(update, segmentLocalPath, segmentStoragePath)
+}
+
+func (s *SegmentSink) uploadSegment(update SegmentUpdate, segmentLocalPath, segmentStoragePath string) {
+	defer close(update.uploadComplete)
+
+	_, size, err := s.Upload(segmentLocalPath, segmentStoragePath, s.outputType, true, "segment")
+	if err != nil {
+		s.callbacks.OnError(err)
+		return
+	}
+
+	// lock segment info updates
+	s.infoLock.Lock()
+	s.SegmentsInfo.SegmentCount++
+	s.SegmentsInfo.Size += size
+	s.infoLock.Unlock()
 }
 
 func (s *SegmentSink) handlePlaylistUpdates(update SegmentUpdate) error {
@@ -182,24 +239,41 @@ func (s *SegmentSink) handlePlaylistUpdates(update SegmentUpdate) error {
 	s.playlistLock.Lock()
 	defer s.playlistLock.Unlock()
 
-	if err := s.playlist.Append(segmentStartTime, duration, update.filename); err != nil {
+	if err := s.appendIntro(segmentStartTime); err != nil {
 		return err
 	}
+	if err := appendSegment(s.playlist, segmentStartTime, duration, update.filename, update.fragment); err != nil {
+		return err
+	}
+	s.lastSegmentEnd = segmentStartTime.Add(time.Duration(update.endTime - t))
 	if err := s.uploadPlaylist(); err != nil {
 		s.callbacks.OnError(err)
 	}
 	if s.livePlaylist != nil {
-		if err := s.livePlaylist.Append(segmentStartTime, duration, update.filename); err != nil {
+		if err := appendSegment(s.livePlaylist, segmentStartTime, duration, update.filename, update.fragment); err != nil {
 			return err
 		}
 		if err := s.uploadLivePlaylist(); err != nil {
 			s.callbacks.OnError(err)
 		}
 	}
+	if s.subtitles != nil {
+		if err := s.appendSubtitles(segmentStartTime, duration, update.filename, t, update.endTime); err != nil {
+			s.callbacks.OnError(err)
+		}
+	}
 
 	return nil
 }
 
+// appendSegment adds a segment to a playlist, along with its init section when the segments are fmp4
+func appendSegment(playlist m3u8.PlaylistWriter, dateTime time.Time, duration float64, filename string, fragment *m3u8.Fragment) error {
+	if fragment != nil {
+		return playlist.AppendFragment(dateTime, duration, filename, fragment)
+	}
+	return playlist.Append(dateTime, duration, filename)
+}
+
 func (s *SegmentSink) UpdateStartDate(t time.Time) {
 	s.segmentLock.Lock()
 	defer s.segmentLock.Unlock()
@@ -260,7 +334,17 @@ func (s *SegmentSink) Close() error {
 	s.playlistLock.Lock()
 	defer s.playlistLock.Unlock()
 
-	if err := s.playlist.Close(); err != nil {
+	if err := s.appendIntro(time.Now()); err != nil {
+		return err
+	}
+	outro, err := s.appendOutro()
+	if err != nil {
+		// the live segments are still complete
+		logger.Warnw("failed to add outro", err)
+	}
+	s.SegmentsInfo.Duration += int64(getClipSegmentsDuration(s.intro) + outro)
+
+	if err = s.playlist.Close(); err != nil {
 		return err
 	}
 	if err := s.uploadPlaylist(); err != nil {
@@ -276,12 +360,22 @@ func (s *SegmentSink) Close() error {
 		}
 	}
 
+	playlistLocalPath := path.Join(s.LocalDir, s.PlaylistFilename)
+	playlistStoragePath := path.Join(s.StorageDir, s.PlaylistFilename)
+	sidecars, err := uploadSidecars(s.conf, s.Uploader, playlistLocalPath, playlistStoragePath, s.ContentOffset.Load())
+	if err != nil {
+		return err
+	}
+	if s.subtitles != nil {
+		if err = s.closeSubtitles(); err != nil {
+			return err
+		}
+	}
+
 	if !s.DisableManifest {
-		playlistLocalPath := path.Join(s.LocalDir, s.PlaylistFilename)
-		playlistStoragePath := path.Join(s.StorageDir, s.PlaylistFilename)
 		manifestLocalPath := fmt.Sprintf("%s.json", playlistLocalPath)
 		manifestStoragePath := fmt.Sprintf("%s.json", playlistStoragePath)
-		if err := uploadManifest(s.conf, s.Uploader, manifestLocalPath, manifestStoragePath); err != nil {
+		if err = uploadManifest(s.conf, s.Uploader, manifestLocalPath, manifestStoragePath, sidecars, s.ContentOffset.Load()); err != nil {
 			return err
 		}
 	}
@@ -303,17 +397,21 @@ func (s *SegmentSink) Cleanup() {
 }
 
 func (s *SegmentSink) uploadPlaylist() error {
-	var err error
 	playlistLocalPath := path.Join(s.LocalDir, s.PlaylistFilename)
 	playlistStoragePath := path.Join(s.StorageDir, s.PlaylistFilename)
-	s.SegmentsInfo.PlaylistLocation, _, err = s.Upload(playlistLocalPath, playlistStoragePath, s.OutputType, false, "playlist")
+	location, _, err := s.Upload(playlistLocalPath, playlistStoragePath, s.OutputType, false, "playlist")
+	if err == nil && s.subtitles == nil {
+		s.SegmentsInfo.PlaylistLocation = location
+	}
 	return err
 }
 
 func (s *SegmentSink) uploadLivePlaylist() error {
-	var err error
 	liveLocalPath := path.Join(s.LocalDir, s.LivePlaylistFilename)
 	liveStoragePath := path.Join(s.StorageDir, s.LivePlaylistFilename)
-	s.SegmentsInfo.LivePlaylistLocation, _, err = s.Upload(liveLocalPath, liveStoragePath, s.OutputType, false, "live_playlist")
+	location, _, err := s.Upload(liveLocalPath, liveStoragePath, s.OutputType, false, "live_playlist")
+	if err == nil && s.subtitles == nil {
+		s.SegmentsInfo.LivePlaylistLocation = location
+	}
 	return err
 }
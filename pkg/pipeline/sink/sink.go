@@ -80,10 +80,11 @@ func CreateSinks(p *config.PipelineConfig, callbacks *gstreamer.Callbacks, monit
 					return nil, err
 				}
 
-				s, err = newImageSink(u, p, o, callbacks)
+				imageSink, err := newImageSink(u, p, o, callbacks)
 				if err != nil {
 					return nil, err
 				}
+				sinks[egressType] = append(sinks[egressType], imageSink)
 			}
 		}
 
@@ -0,0 +1,47 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/gstreamer"
+	"github.com/livekit/egress/pkg/info"
+	"github.com/livekit/egress/pkg/types"
+)
+
+func TestCreateImageSinks(t *testing.T) {
+	p := &config.PipelineConfig{
+		Info: &info.EgressInfo{EgressId: "EG_test"},
+		Outputs: map[types.EgressType][]config.OutputConfig{
+			types.EgressTypeImages: {
+				&config.ImageConfig{Id: "thumbnails"},
+				&config.ImageConfig{Id: "keyframes"},
+			},
+		},
+	}
+
+	sinks, err := CreateSinks(p, &gstreamer.Callbacks{}, nil)
+	require.NoError(t, err)
+
+	// every image output gets its own sink
+	require.Len(t, sinks[types.EgressTypeImages], 2)
+	for i, id := range []string{"thumbnails", "keyframes"} {
+		require.Equal(t, id, sinks[types.EgressTypeImages][i].(*ImageSink).Id)
+	}
+}
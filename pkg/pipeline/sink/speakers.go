@@ -0,0 +1,73 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/pipeline/sink/uploader"
+	"github.com/livekit/egress/pkg/types"
+)
+
+type SpeakingInterval struct {
+	Identity string  `json:"identity"`
+	Start    float64 `json:"start"` // seconds from the start of the recording
+	End      float64 `json:"end"`
+}
+
+func getSpeakingTimeline(p *config.PipelineConfig, offset time.Duration) []*SpeakingInterval {
+	intervals := p.Speakers.Close()
+	timeline := make([]*SpeakingInterval, 0, len(intervals))
+	for _, interval := range intervals {
+		timeline = append(timeline, &SpeakingInterval{
+			Identity: interval.Identity,
+			Start:    (interval.Start + offset).Seconds(),
+			End:      (interval.End + offset).Seconds(),
+		})
+	}
+	return timeline
+}
+
+// uploadSpeakingTimeline uploads the timeline as {filepath}.speakers.json and {filepath}.speakers.vtt
+func uploadSpeakingTimeline(p *config.PipelineConfig, u uploader.Uploader, localFilepath, storageFilepath string, offset time.Duration) ([]*Sidecar, error) {
+	b, err := json.Marshal(getSpeakingTimeline(p, offset))
+	if err != nil {
+		return nil, err
+	}
+	jsonSidecar, err := uploadSidecar(u, b, localFilepath, storageFilepath, ".speakers.json", types.OutputTypeJSON, "speakers")
+	if err != nil {
+		return nil, err
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString(webVTTHeader)
+	for _, interval := range p.Speakers.Close() {
+		writeWebVTTCue(sb, &captionCue{
+			Start: interval.Start + offset,
+			End:   interval.End + offset,
+			Voice: interval.Identity,
+			Text:  interval.Identity,
+		})
+	}
+	vttSidecar, err := uploadSidecar(u, []byte(sb.String()), localFilepath, storageFilepath, ".speakers.vtt", types.OutputTypeVTT, "speakers")
+	if err != nil {
+		return nil, err
+	}
+
+	return []*Sidecar{jsonSidecar, vttSidecar}, nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/types"
+)
+
+type spriteSheet struct {
+	index int
+	img   *image.RGBA
+	tiles int
+	start time.Time
+}
+
+type spriteTile struct {
+	start    time.Duration
+	end      time.Duration
+	filename string
+	rect     image.Rectangle
+}
+
+// addTile draws a captured frame onto the current sprite sheet, uploading the sheet once it is full
+func (s *ImageSink) addTile(localPath string, ts time.Time) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	frame, _, err := image.Decode(f)
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(localPath); err != nil {
+		return err
+	}
+
+	cols, rows := int(s.Sprites.Columns), int(s.Sprites.Rows)
+	w, h := int(s.Sprites.TileWidth), int(s.Sprites.TileHeight)
+	if s.sprite == nil {
+		s.sprite = &spriteSheet{
+			index: s.spriteCount,
+			img:   image.NewRGBA(image.Rect(0, 0, cols*w, rows*h)),
+			start: ts,
+		}
+		s.spriteCount++
+	}
+
+	x, y := s.sprite.tiles%cols*w, s.sprite.tiles/cols*h
+	rect := image.Rect(x, y, x+w, y+h)
+	draw.Draw(s.sprite.img, rect, frame, frame.Bounds().Min, draw.Src)
+	s.sprite.tiles++
+
+	start := ts.Sub(s.startTime)
+	s.tiles = append(s.tiles, &spriteTile{
+		start:    start,
+		end:      start + time.Duration(s.CaptureInterval)*time.Second,
+		filename: s.getSpriteFilename(s.sprite.index),
+		rect:     rect,
+	})
+
+	if s.sprite.tiles == cols*rows {
+		return s.uploadSprite()
+	}
+	return nil
+}
+
+func (s *ImageSink) getSpriteFilename(index int) string {
+	return fmt.Sprintf("%s_sprite_%05d%s", s.ImagePrefix, index, types.FileExtensionForOutputType[s.OutputType])
+}
+
+// uploadSprite uploads the current sheet, followed by the updated thumbnails track
+func (s *ImageSink) uploadSprite() error {
+	sprite := s.sprite
+	s.sprite = nil
+
+	// crop unused rows
+	cols, h := int(s.Sprites.Columns), int(s.Sprites.TileHeight)
+	rows := (sprite.tiles + cols - 1) / cols
+	img := sprite.img.SubImage(image.Rect(0, 0, sprite.img.Bounds().Dx(), rows*h))
+
+	filename := s.getSpriteFilename(sprite.index)
+	localPath := path.Join(s.LocalDir, filename)
+	f, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+	switch s.ImageOutCodec {
+	case types.MimeTypeJPEG:
+		err = jpeg.Encode(f, img, nil)
+	case types.MimeTypePNG:
+		err = png.Encode(f, img)
+	default:
+		err = errors.ErrNoCompatibleCodec
+	}
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+
+	_, size, err := s.Upload(localPath, path.Join(s.StorageDir, filename), s.OutputType, true, "sprite")
+	if err != nil {
+		return err
+	}
+	s.ImagesInfo.ImageCount++
+
+	thumbnails, err := s.uploadThumbnails()
+	if err != nil {
+		return err
+	}
+
+	if !s.DisableManifest {
+		s.manifest.spriteCreated(filename, sprite.start, size, sprite.tiles, thumbnails)
+		return s.uploadManifest()
+	}
+	return nil
+}
+
+// uploadThumbnails uploads {prefix}_thumbnails.vtt, mapping time ranges to sprite tiles
+func (s *ImageSink) uploadThumbnails() (string, error) {
+	sb := &strings.Builder{}
+	sb.WriteString(webVTTHeader)
+	for _, tile := range s.tiles {
+		writeWebVTTCue(sb, &captionCue{
+			Start: tile.start,
+			End:   tile.end,
+			Text: fmt.Sprintf("%s#xywh=%d,%d,%d,%d",
+				tile.filename, tile.rect.Min.X, tile.rect.Min.Y, tile.rect.Dx(), tile.rect.Dy(),
+			),
+		})
+	}
+
+	filename := fmt.Sprintf("%s_thumbnails.vtt", s.ImagePrefix)
+	localPath := path.Join(s.LocalDir, filename)
+	if err := os.WriteFile(localPath, []byte(sb.String()), 0644); err != nil {
+		return "", err
+	}
+	if _, _, err := s.Upload(localPath, path.Join(s.StorageDir, filename), types.OutputTypeVTT, false, "thumbnails"); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/pipeline/sink/m3u8"
+	"github.com/livekit/egress/pkg/types"
+)
+
+const (
+	// mpegtsmux offsets timestamps by one hour
+	tsClockBase = uint64(90000 * 3600)
+	tsClockRate = uint64(90000)
+)
+
+// subtitleRendition writes transcriptions as a segmented WebVTT rendition, aligned with the media segments
+type subtitleRendition struct {
+	recorder *config.DataRecorder
+
+	playlist             m3u8.PlaylistWriter
+	livePlaylist         m3u8.PlaylistWriter
+	playlistFilename     string
+	livePlaylistFilename string
+
+	masterFilename     string
+	liveMasterFilename string
+}
+
+func newSubtitleRendition(p *config.PipelineConfig, o *config.SegmentConfig) (*subtitleRendition, error) {
+	r := &subtitleRendition{
+		recorder:         p.DataRecorder,
+		playlistFilename: getRenditionFilename(o.PlaylistFilename, "subtitles"),
+		masterFilename:   getRenditionFilename(o.PlaylistFilename, "master"),
+	}
+
+	var err error
+	r.playlist, err = m3u8.NewEventPlaylistWriter(path.Join(o.LocalDir, r.playlistFilename), o.SegmentDuration, false)
+	if err != nil {
+		return nil, err
+	}
+	if err = m3u8.WriteMasterPlaylist(path.Join(o.LocalDir, r.masterFilename), newMasterPlaylist(p, o.PlaylistFilename, r.playlistFilename)); err != nil {
+		return nil, err
+	}
+	// players should load the master playlist to find the subtitles
+	o.SegmentsInfo.PlaylistName = path.Join(o.StorageDir, r.masterFilename)
+
+	if o.LivePlaylistFilename != "" {
+		r.livePlaylistFilename = getRenditionFilename(o.LivePlaylistFilename, "subtitles")
+		r.liveMasterFilename = getRenditionFilename(o.LivePlaylistFilename, "master")
+
+		r.livePlaylist, err = m3u8.NewLivePlaylistWriter(path.Join(o.LocalDir, r.livePlaylistFilename), o.SegmentDuration, defaultLivePlaylistWindow, false)
+		if err != nil {
+			return nil, err
+		}
+		if err = m3u8.WriteMasterPlaylist(path.Join(o.LocalDir, r.liveMasterFilename), newMasterPlaylist(p, o.LivePlaylistFilename, r.livePlaylistFilename)); err != nil {
+			return nil, err
+		}
+		o.SegmentsInfo.LivePlaylistName = path.Join(o.StorageDir, r.liveMasterFilename)
+	}
+
+	return r, nil
+}
+
+// getRenditionFilename returns {name}-{rendition}.m3u8
+func getRenditionFilename(playlistFilename, rendition string) string {
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(playlistFilename, string(types.FileExtensionM3U8)), rendition, types.FileExtensionM3U8)
+}
+
+func newMasterPlaylist(p *config.PipelineConfig, playlist, subtitles string) *m3u8.MasterPlaylist {
+	m := &m3u8.MasterPlaylist{
+		Playlist:          playlist,
+		Subtitles:         subtitles,
+		SubtitlesName:     "Captions",
+		SubtitlesLanguage: p.DataCapture.Language,
+	}
+	if p.AudioEnabled {
+		m.Bandwidth += int(p.AudioBitrate) * 1000
+	}
+	if p.VideoEnabled {
+		m.Bandwidth += int(p.VideoBitrate) * 1000
+		m.Resolution = fmt.Sprintf("%dx%d", p.Width, p.Height)
+	}
+	return m
+}
+
+// uploadMasterPlaylists uploads the master playlists, which are reported instead of the media playlists
+func (s *SegmentSink) uploadMasterPlaylists() error {
+	var err error
+	s.SegmentsInfo.PlaylistLocation, _, err = s.Upload(
+		path.Join(s.LocalDir, s.subtitles.masterFilename),
+		path.Join(s.StorageDir, s.subtitles.masterFilename),
+		s.OutputType, false, "master_playlist",
+	)
+	if err != nil || s.subtitles.liveMasterFilename == "" {
+		return err
+	}
+
+	s.SegmentsInfo.LivePlaylistLocation, _, err = s.Upload(
+		path.Join(s.LocalDir, s.subtitles.liveMasterFilename),
+		path.Join(s.StorageDir, s.subtitles.liveMasterFilename),
+		s.OutputType, false, "master_playlist",
+	)
+	return err
+}
+
+// appendSubtitles writes cues overlapping the segment to a .vtt file with the same name
+func (s *SegmentSink) appendSubtitles(dateTime time.Time, duration float64, segmentFilename string, startTime, endTime uint64) error {
+	start := time.Duration(startTime - s.startRunningTime)
+	end := time.Duration(endTime - s.startRunningTime)
+
+	sb := &strings.Builder{}
+	sb.WriteString(webVTTHeader)
+	sb.WriteString(getTimestampMap(s.outputType, s.startRunningTime))
+	for _, transcription := range s.subtitles.recorder.TranscriptionsBetween(start, end) {
+		writeWebVTTCue(sb, &captionCue{
+			Start: transcription.Start,
+			End:   transcription.End,
+			Voice: transcription.Identity,
+			Text:  transcription.Text,
+		})
+	}
+
+	return s.writeSubtitles(dateTime, duration, segmentFilename, sb.String())
+}
+
+// getTimestampMap maps cue times to the media timestamps of the first segment. Only mpegtsmux adds
+// the one hour offset, fmp4 segments start from the running time
+func getTimestampMap(outputType types.OutputType, startRunningTime uint64) string {
+	base := tsClockBase
+	if outputType == types.OutputTypeM4S {
+		base = 0
+	}
+	return fmt.Sprintf(
+		"X-TIMESTAMP-MAP=MPEGTS:%d,LOCAL:00:00:00.000\n",
+		base+startRunningTime*tsClockRate/uint64(time.Second),
+	)
+}
+
+// writeSubtitles uploads a .vtt file with the same name as the segment and adds it to the rendition
+func (s *SegmentSink) writeSubtitles(dateTime time.Time, duration float64, segmentFilename, vtt string) error {
+	filename := fmt.Sprintf("%s.vtt", strings.TrimSuffix(segmentFilename, path.Ext(segmentFilename)))
+	localPath := path.Join(s.LocalDir, filename)
+	if err := os.WriteFile(localPath, []byte(vtt), 0644); err != nil {
+		return err
+	}
+	if _, _, err := s.Upload(localPath, path.Join(s.StorageDir, filename), types.OutputTypeVTT, true, "subtitles"); err != nil {
+		return err
+	}
+
+	if err := s.subtitles.playlist.Append(dateTime, duration, filename); err != nil {
+		return err
+	}
+	if err := s.uploadSubtitlesPlaylist(s.subtitles.playlistFilename); err != nil {
+		return err
+	}
+	if s.subtitles.livePlaylist != nil {
+		if err := s.subtitles.livePlaylist.Append(dateTime, duration, filename); err != nil {
+			return err
+		}
+		if err := s.uploadSubtitlesPlaylist(s.subtitles.livePlaylistFilename); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *SegmentSink) closeSubtitles() error {
+	if err := s.subtitles.playlist.Close(); err != nil {
+		return err
+	}
+	if err := s.uploadSubtitlesPlaylist(s.subtitles.playlistFilename); err != nil {
+		return err
+	}
+	if s.subtitles.livePlaylist != nil {
+		if err := s.subtitles.livePlaylist.Close(); err != nil {
+			return err
+		}
+		if err := s.uploadSubtitlesPlaylist(s.subtitles.livePlaylistFilename); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SegmentSink) uploadSubtitlesPlaylist(filename string) error {
+	_, _, err := s.Upload(path.Join(s.LocalDir, filename), path.Join(s.StorageDir, filename), s.OutputType, false, "subtitles_playlist")
+	return err
+}
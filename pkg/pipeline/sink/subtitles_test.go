@@ -0,0 +1,32 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/egress/pkg/types"
+)
+
+func TestTimestampMap(t *testing.T) {
+	start := uint64(2 * time.Second)
+
+	// mpegts timestamps start one hour in
+	require.Equal(t, "X-TIMESTAMP-MAP=MPEGTS:324180000,LOCAL:00:00:00.000\n", getTimestampMap(types.OutputTypeTS, start))
+	require.Equal(t, "X-TIMESTAMP-MAP=MPEGTS:180000,LOCAL:00:00:00.000\n", getTimestampMap(types.OutputTypeM4S, start))
+}
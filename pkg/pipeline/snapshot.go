@@ -0,0 +1,86 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/ipc"
+	"github.com/livekit/egress/pkg/pipeline/sink/uploader"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/tracer"
+)
+
+// GetSnapshot encodes the current frame, and uploads it unless it was requested inline
+func (c *Controller) GetSnapshot(ctx context.Context, req *ipc.SnapshotRequest) (*ipc.SnapshotResponse, error) {
+	ctx, span := tracer.Start(ctx, "Pipeline.GetSnapshot")
+	defer span.End()
+
+	if c.videoBin == nil {
+		return nil, errors.ErrNotSupported("snapshots without video")
+	}
+	if req.Width < 0 || req.Height < 0 {
+		return nil, errors.ErrInvalidInput("size")
+	}
+
+	mimeType, outputType, err := config.GetSnapshotOutputType(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := c.videoBin.GetSnapshot(ctx, mimeType, req.Width, req.Height)
+	if err != nil {
+		return nil, err
+	}
+	if req.Inline {
+		return &ipc.SnapshotResponse{
+			Image:    b,
+			MimeType: string(mimeType),
+		}, nil
+	}
+
+	storagePath, err := c.GetSnapshotFilepath(req.Filepath, mimeType, outputType)
+	if err != nil {
+		return nil, err
+	}
+
+	// without storage, the snapshot stays in the tmp dir
+	localPath := path.Join(c.TmpDir, storagePath)
+	if err = os.MkdirAll(path.Dir(localPath), 0755); err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(localPath, b, 0644); err != nil {
+		return nil, err
+	}
+
+	u, err := uploader.New(c.GetSnapshotUploadConfig(), "", c.monitor)
+	if err != nil {
+		return nil, err
+	}
+	location, _, err := u.Upload(localPath, storagePath, outputType, true, "snapshot")
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debugw("snapshot uploaded", "location", location)
+	return &ipc.SnapshotResponse{
+		Location: location,
+		MimeType: string(mimeType),
+	}, nil
+}
@@ -155,9 +155,18 @@ func (s *SDKSource) joinRoom() error {
 		},
 		OnDisconnected: s.onDisconnected,
 	}
-	if s.RequestType == types.RequestTypeParticipant {
+	if s.DataRecorder != nil {
+		cb.ParticipantCallback.OnDataPacket = s.onDataPacket
+	}
+	switch s.RequestType {
+	case types.RequestTypeParticipant:
 		cb.ParticipantCallback.OnTrackPublished = s.onTrackPublished
 		cb.OnParticipantDisconnected = s.onParticipantDisconnected
+	case types.RequestTypeRoomComposite:
+		cb.ParticipantCallback.OnTrackPublished = s.onTrackPublished
+		if s.VideoEnabled {
+			cb.OnActiveSpeakersChanged = s.onActiveSpeakersChanged
+		}
 	}
 
 	logger.Debugw("connecting to room")
@@ -170,6 +179,10 @@ func (s *SDKSource) joinRoom() error {
 	var fileIdentifier string
 	var w, h uint32
 	switch s.RequestType {
+	case types.RequestTypeRoomComposite:
+		fileIdentifier = s.Info.RoomName
+		err = s.awaitRoomTracks()
+
 	case types.RequestTypeParticipant:
 		fileIdentifier = s.Identity
 		s.filenameReplacements["{publisher_identity}"] = s.Identity
@@ -266,6 +279,68 @@ func (s *SDKSource) awaitParticipantTracks(identity string) (uint32, uint32, err
 	}
 }
 
+func (s *SDKSource) awaitRoomTracks() error {
+	// subscribe to any tracks already published
+	expected := 0
+	for _, rp := range s.room.GetRemoteParticipants() {
+		for _, pub := range rp.TrackPublications() {
+			if s.shouldSubscribeRoom(pub) {
+				if err := s.subscribe(pub); err != nil {
+					return err
+				}
+				expected++
+			}
+		}
+	}
+
+	deadline := time.After(time.Second * 3)
+	subscribed := 0
+	done := expected == 0
+	for !done {
+		select {
+		case err := <-s.errors:
+			if err != nil {
+				return err
+			}
+			subscribed++
+			if subscribed == expected {
+				done = true
+			}
+		case <-deadline:
+			done = true
+		}
+	}
+
+	// lock any incoming subscriptions
+	s.subLock.Lock()
+	defer s.subLock.Unlock()
+
+	for {
+		select {
+		// check errors from any tracks published in the meantime
+		case err := <-s.errors:
+			if err != nil {
+				return err
+			}
+		default:
+			// ready
+			s.initialized.Break()
+			return nil
+		}
+	}
+}
+
+func (s *SDKSource) shouldSubscribeRoom(pub lksdk.TrackPublication) bool {
+	switch pub.Kind() {
+	case lksdk.TrackKindAudio:
+		return s.AudioEnabled
+	case lksdk.TrackKindVideo:
+		return s.VideoEnabled
+	default:
+		return false
+	}
+}
+
 func (s *SDKSource) getParticipant(identity string) (*lksdk.RemoteParticipant, error) {
 	deadline := time.Now().Add(subscriptionTimeout)
 	for time.Now().Before(deadline) {
@@ -363,33 +438,54 @@ func (s *SDKSource) subscribe(track lksdk.TrackPublication) error {
 func (s *SDKSource) onTrackSubscribed(track *webrtc.TrackRemote, pub *lksdk.RemoteTrackPublication, rp *lksdk.RemoteParticipant) {
 	s.subLock.RLock()
 
-	if s.initialized.IsBroken() && s.RequestType != types.RequestTypeParticipant {
+	if s.initialized.IsBroken() &&
+		s.RequestType != types.RequestTypeParticipant &&
+		s.RequestType != types.RequestTypeRoomComposite {
 		s.subLock.RUnlock()
 		return
 	}
 
 	var onSubscribeErr error
 	defer func() {
-		if s.initialized.IsBroken() {
+		initialized := s.initialized.IsBroken()
+		// never block while holding the lock, awaitRoomTracks needs it to finish initializing
+		s.subLock.RUnlock()
+
+		if initialized {
+			if onSubscribeErr != nil {
+				s.callbacks.OnError(onSubscribeErr)
+			}
+			return
+		}
+		select {
+		case s.errors <- onSubscribeErr:
+		case <-s.initialized.Watch():
+			// finished waiting before this track was counted
 			if onSubscribeErr != nil {
 				s.callbacks.OnError(onSubscribeErr)
 			}
-		} else {
-			s.errors <- onSubscribeErr
 		}
-		s.subLock.RUnlock()
 	}()
 
 	s.active.Inc()
 	ts := &config.TrackSource{
 		TrackID:     pub.SID(),
+		Identity:    rp.Identity(),
 		Kind:        pub.Kind(),
 		MimeType:    types.MimeType(strings.ToLower(track.Codec().MimeType)),
 		PayloadType: track.Codec().PayloadType,
 		ClockRate:   track.Codec().ClockRate,
+		Width:       pub.TrackInfo().GetWidth(),
+		Height:      pub.TrackInfo().GetHeight(),
 	}
 
 	<-s.callbacks.GstReady
+	if s.RequestType == types.RequestTypeTrack {
+		if onSubscribeErr = s.UpdateTrackOutput(ts); onSubscribeErr != nil {
+			return
+		}
+	}
+
 	switch ts.MimeType {
 	case types.MimeTypeOpus:
 		s.AudioEnabled = true
@@ -410,7 +506,13 @@ func (s *SDKSource) onTrackSubscribed(track *webrtc.TrackRemote, pub *lksdk.Remo
 		s.mu.Unlock()
 
 		if !s.initialized.IsBroken() {
-			s.AudioTrack = ts
+			if s.RequestType == types.RequestTypeRoomComposite {
+				s.mu.Lock()
+				s.AudioTracks = append(s.AudioTracks, ts)
+				s.mu.Unlock()
+			} else {
+				s.AudioTrack = ts
+			}
 		}
 
 	case types.MimeTypeH264, types.MimeTypeVP8, types.MimeTypeVP9:
@@ -426,6 +528,10 @@ func (s *SDKSource) onTrackSubscribed(track *webrtc.TrackRemote, pub *lksdk.Remo
 			}
 		}
 
+		if onSubscribeErr = s.setVideoLayer(pub); onSubscribeErr != nil {
+			return
+		}
+
 		writer, err := s.createWriter(track, pub, rp, ts)
 		if err != nil {
 			onSubscribeErr = err
@@ -437,10 +543,25 @@ func (s *SDKSource) onTrackSubscribed(track *webrtc.TrackRemote, pub *lksdk.Remo
 		s.mu.Unlock()
 
 		if !s.initialized.IsBroken() {
-			s.VideoTrack = ts
+			if s.RequestType == types.RequestTypeRoomComposite {
+				s.mu.Lock()
+				s.VideoTracks = append(s.VideoTracks, ts)
+				s.mu.Unlock()
+			} else {
+				s.VideoTrack = ts
+			}
 		}
 
 	default:
+		if s.RequestType == types.RequestTypeRoomComposite {
+			// one unsupported publisher shouldn't fail the whole room
+			logger.Warnw("skipping track", errors.ErrNotSupported(string(ts.MimeType)), "trackID", ts.TrackID)
+			s.active.Dec()
+			if err := pub.SetSubscribed(false); err != nil {
+				logger.Warnw("failed to unsubscribe", err, "trackID", ts.TrackID)
+			}
+			return
+		}
 		onSubscribeErr = errors.ErrNotSupported(string(ts.MimeType))
 		return
 	}
@@ -466,9 +587,6 @@ func (s *SDKSource) onTrackSubscribed(track *webrtc.TrackRemote, pub *lksdk.Remo
 				return
 			}
 			s.TrackSource = strings.ToLower(pub.Source().String())
-			if o := s.GetFileConfig(); o != nil {
-				o.OutputType = types.TrackOutputTypes[ts.MimeType]
-			}
 
 			s.filenameReplacements["{track_id}"] = s.TrackID
 			s.filenameReplacements["{track_type}"] = s.TrackKind
@@ -479,6 +597,36 @@ func (s *SDKSource) onTrackSubscribed(track *webrtc.TrackRemote, pub *lksdk.Remo
 	}
 }
 
+// setVideoLayer requests the simulcast layer set by the request. The sfu can still switch layers under
+// congestion, so the layer is requested again whenever a smaller one is received
+func (s *SDKSource) setVideoLayer(pub *lksdk.RemoteTrackPublication) error {
+	request := func() error {
+		switch s.SimulcastLayer {
+		case "high":
+			return pub.SetVideoQuality(livekit.VideoQuality_HIGH)
+		case "medium":
+			return pub.SetVideoQuality(livekit.VideoQuality_MEDIUM)
+		case "low":
+			return pub.SetVideoQuality(livekit.VideoQuality_LOW)
+		case "match":
+			// the sfu picks the smallest layer covering the output
+			pub.SetVideoDimensions(uint32(s.Width), uint32(s.Height))
+		}
+		return nil
+	}
+
+	if s.VideoLayers != nil {
+		layers := pub.TrackInfo().GetLayers()
+		held := config.GetHeldVideoLayer(layers, s.SimulcastLayer, s.Width, s.Height)
+		s.VideoLayers.AddTrack(pub.SID(), layers, held, func() {
+			if err := request(); err != nil {
+				logger.Warnw("failed to request video layer", err, "trackID", pub.SID())
+			}
+		})
+	}
+	return request()
+}
+
 func (s *SDKSource) createWriter(
 	track *webrtc.TrackRemote,
 	pub lksdk.TrackPublication,
@@ -503,7 +651,7 @@ func (s *SDKSource) createWriter(
 	}
 
 	ts.AppSrc = app.SrcFromElement(src)
-	writer, err := sdk.NewAppWriter(track, pub, rp, ts, s.sync, s.callbacks, logFilename)
+	writer, err := sdk.NewAppWriter(track, pub, rp, ts, s.sync, s.Quality, s.callbacks, logFilename)
 	if err != nil {
 		return nil, err
 	}
@@ -512,16 +660,27 @@ func (s *SDKSource) createWriter(
 }
 
 func (s *SDKSource) onTrackPublished(pub *lksdk.RemoteTrackPublication, rp *lksdk.RemoteParticipant) {
-	if rp.Identity() != s.Identity || s.RequestType != types.RequestTypeParticipant {
+	switch s.RequestType {
+	case types.RequestTypeParticipant:
+		if rp.Identity() != s.Identity {
+			return
+		}
+		if !shouldSubscribe(pub) {
+			logger.Infow("ignoring participant track", "reason", fmt.Sprintf("source %s", pub.Source()))
+			return
+		}
+
+	case types.RequestTypeRoomComposite:
+		if !s.shouldSubscribeRoom(pub) {
+			return
+		}
+
+	default:
 		return
 	}
 
-	if shouldSubscribe(pub) {
-		if err := s.subscribe(pub); err != nil {
-			logger.Errorw("failed to subscribe to track", err, "trackID", pub.SID())
-		}
-	} else {
-		logger.Infow("ignoring participant track", "reason", fmt.Sprintf("source %s", pub.Source()))
+	if err := s.subscribe(pub); err != nil {
+		logger.Errorw("failed to subscribe to track", err, "trackID", pub.SID())
 	}
 }
 
@@ -552,6 +711,34 @@ func (s *SDKSource) onTrackUnmuted(pub lksdk.TrackPublication, _ lksdk.Participa
 	}
 }
 
+func (s *SDKSource) onActiveSpeakersChanged(speakers []lksdk.Participant) {
+	if !s.initialized.IsBroken() {
+		return
+	}
+
+	identities := make([]string, 0, len(speakers))
+	for _, p := range speakers {
+		identities = append(identities, p.Identity())
+	}
+	s.callbacks.OnActiveSpeakersChanged(identities)
+}
+
+func (s *SDKSource) onDataPacket(data lksdk.DataPacket, params lksdk.DataReceiveParams) {
+	// align with the synchronizer, ignoring anything received before the recording started
+	startedAt := s.sync.GetStartedAt()
+	if startedAt == 0 {
+		return
+	}
+	receivedAt := time.Now().UnixNano()
+
+	switch packet := data.ToProto().Value.(type) {
+	case *livekit.DataPacket_User:
+		s.DataRecorder.OnMessage(params.SenderIdentity, packet.User.GetTopic(), packet.User.Payload, startedAt, receivedAt)
+	case *livekit.DataPacket_Transcription:
+		s.DataRecorder.OnTranscription(packet.Transcription, startedAt, receivedAt)
+	}
+}
+
 func (s *SDKSource) onTrackUnsubscribed(_ *webrtc.TrackRemote, pub *lksdk.RemoteTrackPublication, _ *lksdk.RemoteParticipant) {
 	logger.Debugw("track unsubscribed", "trackID", pub.SID())
 	s.onTrackFinished(pub.SID())
@@ -566,7 +753,7 @@ func (s *SDKSource) onTrackFinished(trackID string) {
 	if writer != nil {
 		writer.Drain(true)
 		active := s.active.Dec()
-		if s.RequestType == types.RequestTypeParticipant {
+		if s.RequestType == types.RequestTypeParticipant || s.RequestType == types.RequestTypeRoomComposite {
 			s.callbacks.OnTrackRemoved(trackID)
 			s.sync.RemoveTrack(trackID)
 		} else if active == 0 {
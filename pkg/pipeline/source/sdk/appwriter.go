@@ -17,6 +17,7 @@ package sdk
 import (
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"os"
 	"time"
@@ -63,6 +64,7 @@ type AppWriter struct {
 	// a/v sync
 	sync *synchronizer.Synchronizer
 	*synchronizer.TrackSynchronizer
+	finishQuality func()
 
 	// state
 	lastRead  time.Time
@@ -79,6 +81,7 @@ func NewAppWriter(
 	rp *lksdk.RemoteParticipant,
 	ts *config.TrackSource,
 	sync *synchronizer.Synchronizer,
+	quality *config.QualityReport,
 	callbacks *gstreamer.Callbacks,
 	logFilename string,
 ) (*AppWriter, error) {
@@ -141,6 +144,10 @@ func NewAppWriter(
 		jitter.WithLogger(w.logger),
 	)
 
+	if quality != nil {
+		w.finishQuality = quality.AddTrack(track.ID(), track.Kind().String(), rp.Identity(), w.getQuality)
+	}
+
 	go w.start()
 	return w, nil
 }
@@ -174,10 +181,23 @@ func (w *AppWriter) start() {
 	if w.logFile != nil {
 		_ = w.logFile.Close()
 	}
+	if w.finishQuality != nil {
+		w.finishQuality()
+	}
 
 	w.finished.Break()
 }
 
+func (w *AppWriter) getQuality() (time.Duration, time.Duration, float64) {
+	stats := w.GetTrackStats()
+	loss := w.buffer.PacketLoss()
+	if math.IsNaN(loss) {
+		// no packets received
+		loss = 0
+	}
+	return time.Duration(stats.AvgDrift), stats.MaxDrift, loss
+}
+
 func (w *AppWriter) readNext() {
 	_ = w.track.SetReadDeadline(time.Now().Add(time.Millisecond * 500))
 	pkt, _, err := w.track.ReadRTP()
@@ -20,6 +20,7 @@ import (
 	"github.com/livekit/egress/pkg/config"
 	"github.com/livekit/egress/pkg/errors"
 	"github.com/livekit/egress/pkg/gstreamer"
+	"github.com/livekit/egress/pkg/stats"
 	"github.com/livekit/egress/pkg/types"
 )
 
@@ -31,15 +32,12 @@ type Source interface {
 	Close()
 }
 
-func New(ctx context.Context, p *config.PipelineConfig, callbacks *gstreamer.Callbacks) (Source, error) {
-	switch p.RequestType {
-	case types.RequestTypeRoomComposite,
-		types.RequestTypeWeb:
-		return NewWebSource(ctx, p)
+func New(ctx context.Context, p *config.PipelineConfig, callbacks *gstreamer.Callbacks, monitor *stats.HandlerMonitor) (Source, error) {
+	switch p.SourceType {
+	case types.SourceTypeWeb:
+		return NewWebSource(ctx, p, callbacks, monitor)
 
-	case types.RequestTypeParticipant,
-		types.RequestTypeTrackComposite,
-		types.RequestTypeTrack:
+	case types.SourceTypeSDK:
 		return NewSDKSource(ctx, p, callbacks)
 
 	default:
@@ -23,15 +23,21 @@ import (
 	"net/url"
 	"os/exec"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/chromedp/cdproto/inspector"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
+	"github.com/frostbyte73/core"
 
 	"github.com/livekit/egress/pkg/config"
 	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/gstreamer"
 	"github.com/livekit/egress/pkg/info"
+	"github.com/livekit/egress/pkg/stats"
 	"github.com/livekit/protocol/logger"
 	"github.com/livekit/protocol/tracer"
 )
@@ -45,21 +51,31 @@ const (
 )
 
 type WebSource struct {
-	pulseSink   string
-	xvfb        *exec.Cmd
+	pulseSink string
+	xvfb      *exec.Cmd
+
+	mu          sync.Mutex
 	closeChrome context.CancelFunc
+	crashed     chan string // reason, reported once per launch
+	crashes     *chromeCrashLog
+	closed      core.Fuse
 
 	startRecording chan struct{}
+	startSignal    chan struct{} // START_RECORDING logged by the page
+	startOnce      sync.Once
 	endRecording   chan struct{}
+	endOnce        sync.Once
 
-	info *info.EgressInfo
+	callbacks *gstreamer.Callbacks
+	monitor   *stats.HandlerMonitor
+	info      *info.EgressInfo
 }
 
 func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
-func NewWebSource(ctx context.Context, p *config.PipelineConfig) (*WebSource, error) {
+func NewWebSource(ctx context.Context, p *config.PipelineConfig, callbacks *gstreamer.Callbacks, monitor *stats.HandlerMonitor) (*WebSource, error) {
 	ctx, span := tracer.Start(ctx, "WebInput.New")
 	defer span.End()
 
@@ -67,11 +83,26 @@ func NewWebSource(ctx context.Context, p *config.PipelineConfig) (*WebSource, er
 
 	s := &WebSource{
 		endRecording: make(chan struct{}),
+		crashed:      make(chan string, 1),
+		crashes:      newChromeCrashLog(),
+		callbacks:    callbacks,
+		monitor:      monitor,
 		info:         p.Info,
 	}
-	if p.AwaitStartSignal {
+
+	webUrl, err := getWebUrl(p)
+	if err != nil {
+		return nil, err
+	}
+	if p.AwaitStartSignal || p.Web.WaitFor != nil {
 		s.startRecording = make(chan struct{})
 	}
+	if p.AwaitStartSignal {
+		s.startSignal = make(chan struct{})
+	}
+	if capture := p.Web.Capture; capture != nil && capture.Selector == "" {
+		p.SetCaptureRegion(capture.X, capture.Y, capture.Width, capture.Height)
+	}
 
 	if err := s.createPulseSink(ctx, p); err != nil {
 		logger.Errorw("failed to create pulse sink", err)
@@ -85,31 +116,22 @@ func NewWebSource(ctx context.Context, p *config.PipelineConfig) (*WebSource, er
 		return nil, err
 	}
 
-	var err error
-	maxRetries := 5
-	retryDelay := time.Second * 10
+	recovery := p.GetChromeRecovery()
+	maxRetries := recovery.LaunchAttempts
+	retryDelay := recovery.LaunchRetryDelay
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		chromeErr := make(chan error, 1)
-		go func() {
-			chromeErr <- s.launchChrome(ctx, p, p.Insecure)
-		}()
-		select {
-		case err = <-chromeErr:
-			// chrome launch completed
-		case <-time.After(chromeTimeout):
-			err = errors.ErrPageLoadFailed("timed out")
-		}
+		err = s.launchChromeWithTimeout(ctx, p, webUrl)
 		if err == nil {
 			// Success
+			go s.monitorChrome(recovery.MaxRelaunches, func() error {
+				return s.relaunchChrome(ctx, p, webUrl)
+			})
 			return s, nil
 		}
 
 		// Close Chrome if it's still running
-		if s.closeChrome != nil {
-			s.closeChrome()
-			s.closeChrome = nil
-		}
+		s.stopChrome()
 
 		logger.Warnw("failed to launch chrome", err, "attempt", attempt)
 		if attempt < maxRetries {
@@ -140,11 +162,15 @@ func (s *WebSource) GetEndedAt() int64 {
 }
 
 func (s *WebSource) Close() {
+	s.closed.Break()
+
+	s.mu.Lock()
 	if s.closeChrome != nil {
 		logger.Debugw("closing chrome")
 		s.closeChrome()
 		s.closeChrome = nil
 	}
+	s.mu.Unlock()
 
 	if s.xvfb != nil {
 		logger.Debugw("closing X display")
@@ -210,26 +236,33 @@ func (s *WebSource) launchXvfb(ctx context.Context, p *config.PipelineConfig) er
 	return nil
 }
 
+func getWebUrl(p *config.PipelineConfig) (string, error) {
+	if p.WebUrl != "" {
+		return p.WebUrl, nil
+	}
+
+	// build input url
+	inputUrl, err := url.Parse(p.BaseUrl)
+	if err != nil {
+		return "", err
+	}
+	values := inputUrl.Query()
+	values.Set("layout", p.Layout)
+	values.Set("url", p.WsUrl)
+	values.Set("token", p.Token)
+	inputUrl.RawQuery = values.Encode()
+	return inputUrl.String(), nil
+}
+
 // launches chrome and navigates to the url
-func (s *WebSource) launchChrome(ctx context.Context, p *config.PipelineConfig, insecure bool) error {
+func (s *WebSource) launchChrome(
+	ctx context.Context,
+	p *config.PipelineConfig,
+	webUrl string,
+) error {
 	ctx, span := tracer.Start(ctx, "WebInput.launchChrome")
 	defer span.End()
 
-	webUrl := p.WebUrl
-	if webUrl == "" {
-		// build input url
-		inputUrl, err := url.Parse(p.BaseUrl)
-		if err != nil {
-			return err
-		}
-		values := inputUrl.Query()
-		values.Set("layout", p.Layout)
-		values.Set("url", p.WsUrl)
-		values.Set("token", p.Token)
-		inputUrl.RawQuery = values.Encode()
-		webUrl = inputUrl.String()
-	}
-
 	logger.Debugw("launching chrome", "url", webUrl, "sandbox", p.EnableChromeSandbox, "insecure", p.Insecure)
 
 	opts := []chromedp.ExecAllocatorOption{
@@ -278,7 +311,7 @@ func (s *WebSource) launchChrome(ctx context.Context, p *config.PipelineConfig,
 		chromedp.Flag("no-sandbox", !p.EnableChromeSandbox),
 	}
 
-	if insecure {
+	if p.Insecure {
 		opts = append(opts,
 			chromedp.Flag("disable-web-security", true),
 			chromedp.Flag("allow-running-insecure-content", true),
@@ -287,17 +320,48 @@ func (s *WebSource) launchChrome(ctx context.Context, p *config.PipelineConfig,
 
 	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
 	chromeCtx, chromeCancel := chromedp.NewContext(allocCtx)
-	s.closeChrome = func() {
+	var closing, launched atomic.Bool
+	closeChrome := func() {
+		closing.Store(true)
 		chromeCancel()
 		allocCancel()
 	}
 
-	// Enable network tracking
-	if err := chromedp.Run(chromeCtx, network.Enable()); err != nil {
+	s.mu.Lock()
+	if ctx.Err() != nil || s.closed.IsBroken() {
+		// the launch was abandoned
+		s.mu.Unlock()
+		closeChrome()
+		return errors.ErrPageLoadFailed("launch cancelled")
+	}
+	s.closeChrome = closeChrome
+	s.mu.Unlock()
+
+	// chrome is closed if the launch times out, but outlives the launch context once loaded
+	stop := context.AfterFunc(ctx, closeChrome)
+	defer stop()
+
+	onCrash := func(reason string) {
+		if launched.Load() && closing.CompareAndSwap(false, true) {
+			select {
+			case s.crashed <- reason:
+			default:
+			}
+		}
+	}
+	go func() {
+		// chromedp cancels the context when the browser process exits
+		<-chromeCtx.Done()
+		onCrash(crashProcessExited)
+	}()
+
+	// Enable network tracking and crash events
+	if err := chromedp.Run(chromeCtx, network.Enable(), inspector.Enable()); err != nil {
 		logger.Errorw("failed to enable network tracking", err)
 		return err
 	}
 
+	requests := newNetworkTracker()
 	chromedp.ListenTarget(chromeCtx, func(ev interface{}) {
 		switch ev := ev.(type) {
 		case *runtime.EventConsoleAPICalled:
@@ -321,27 +385,31 @@ func (s *WebSource) launchChrome(ctx context.Context, p *config.PipelineConfig,
 				switch fmt.Sprint(val) {
 				case startRecordingLog:
 					logger.Infow("chrome: START_RECORDING")
-					if s.startRecording != nil {
+					if s.startSignal != nil {
 						select {
-						case <-s.startRecording:
+						case <-s.startSignal:
 							continue
 						default:
-							close(s.startRecording)
+							close(s.startSignal)
 						}
 					}
 				case endRecordingLog:
 					logger.Infow("chrome: END_RECORDING")
-					if s.endRecording != nil {
-						select {
-						case <-s.endRecording:
-							continue
-						default:
-							close(s.endRecording)
-						}
-					}
+					s.signalEnd()
 				}
 			}
 
+		case *network.EventRequestWillBeSent, *network.EventLoadingFinished, *network.EventLoadingFailed:
+			requests.handleEvent(ev)
+
+		case *inspector.EventTargetCrashed:
+			logger.Warnw("chrome target crashed", nil)
+			onCrash(crashRendererCrashed)
+
+		case *inspector.EventDetached:
+			logger.Warnw("chrome target detached", nil, "reason", ev.Reason)
+			onCrash(crashTargetDetached)
+
 		case *runtime.EventExceptionThrown:
 			logChrome("exception", ev)
 
@@ -354,8 +422,14 @@ func (s *WebSource) launchChrome(ctx context.Context, p *config.PipelineConfig,
 		}
 	})
 
+	stopSeeding, err := applyWebOptions(chromeCtx, &p.Web, webUrl)
+	if err != nil {
+		logger.Errorw("failed to apply web options", err)
+		return err
+	}
+
 	var errString string
-	err := chromedp.Run(chromeCtx,
+	err = chromedp.Run(chromeCtx,
 		chromedp.Navigate(webUrl),
 		chromedp.Evaluate(`
 			if (document.querySelector('div.error')) {
@@ -374,10 +448,47 @@ func (s *WebSource) launchChrome(ctx context.Context, p *config.PipelineConfig,
 	if errString != "" {
 		return errors.ErrPageLoadFailed(errString)
 	}
+	if err = stopSeeding(); err != nil {
+		logger.Warnw("failed to stop seeding storage", err)
+	}
 
+	// ximagesrc can't move its crop once running, so the element is only measured here.
+	// Elements which move or resize should use fill instead
+	if capture := p.Web.Capture; capture != nil && capture.Selector != "" && !capture.Fill {
+		x, y, width, height, err := measureElement(chromeCtx, capture.Selector)
+		if err != nil {
+			return errors.ErrPageLoadFailed(fmt.Sprintf("capture element %s not found: %v", capture.Selector, err))
+		}
+		p.SetCaptureRegion(x, y, width, height)
+		logger.Infow("capturing element", "selector", capture.Selector, "region", p.CaptureRegion)
+	}
+
+	if s.startRecording != nil {
+		go s.awaitStart(chromeCtx, p.Web.WaitFor, requests)
+	}
+	launched.Store(true)
 	return nil
 }
 
+// awaitStart starts recording once the page is ready and, if awaited, START_RECORDING has been logged
+func (s *WebSource) awaitStart(ctx context.Context, waitFor *config.WebWaitCondition, requests *networkTracker) {
+	if waitFor != nil {
+		waitForPage(ctx, waitFor, requests)
+	}
+	if s.startSignal != nil {
+		select {
+		case <-ctx.Done():
+		case <-s.startSignal:
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		close(s.startRecording)
+	})
+}
+
 func logChrome(eventType string, ev interface{ MarshalJSON() ([]byte, error) }) {
 	values := make([]interface{}, 0)
 	if j, err := ev.MarshalJSON(); err == nil {
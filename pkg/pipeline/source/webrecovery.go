@@ -0,0 +1,212 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	crashRendererCrashed = "renderer_crashed"
+	crashTargetDetached  = "target_detached"
+	crashProcessExited   = "process_exited"
+)
+
+type chromeCrash struct {
+	at        time.Time
+	reason    string
+	recovered bool
+}
+
+// chromeCrashLog records chrome crashes during a web egress
+type chromeCrashLog struct {
+	mu      sync.Mutex
+	crashes []*chromeCrash
+}
+
+func newChromeCrashLog() *chromeCrashLog {
+	return &chromeCrashLog{}
+}
+
+func (l *chromeCrashLog) addCrash(reason string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.crashes = append(l.crashes, &chromeCrash{
+		at:     time.Now(),
+		reason: reason,
+	})
+}
+
+// setRecovered marks the latest crash as recovered
+func (l *chromeCrashLog) setRecovered() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.crashes) > 0 {
+		l.crashes[len(l.crashes)-1].recovered = true
+	}
+}
+
+// summary describes the crashes, e.g. "chrome crashed 2 times (renderer crashed, process exited), recovered 1"
+func (l *chromeCrashLog) summary() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.crashes) == 0 {
+		return ""
+	}
+
+	reasons := make([]string, 0, len(l.crashes))
+	recovered := 0
+	for _, c := range l.crashes {
+		reasons = append(reasons, c.reason)
+		if c.recovered {
+			recovered++
+		}
+	}
+
+	times := "times"
+	if len(l.crashes) == 1 {
+		times = "time"
+	}
+	return fmt.Sprintf("chrome crashed %d %s (%s), recovered %d", len(l.crashes), times, strings.Join(reasons, ", "), recovered)
+}
+
+// CrashSummary describes any chrome crashes, for the egress info details
+func (s *WebSource) CrashSummary() string {
+	return s.crashes.summary()
+}
+
+func (s *WebSource) launchChromeWithTimeout(
+	ctx context.Context,
+	p *config.PipelineConfig,
+	webUrl string,
+) error {
+	// cancelled if the launch is abandoned, closing chrome
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chromeErr := make(chan error, 1)
+	go func() {
+		chromeErr <- s.launchChrome(ctx, p, webUrl)
+	}()
+
+	select {
+	case err := <-chromeErr:
+		return err
+	case <-time.After(chromeTimeout):
+		return errors.ErrPageLoadFailed("timed out")
+	case <-s.closed.Watch():
+		return errors.ErrPageLoadFailed("source closed")
+	}
+}
+
+// monitorChrome relaunches chrome on the same display and pulse sink after a crash, showing the slate while the page reloads
+func (s *WebSource) monitorChrome(maxRelaunches int, relaunch func() error) {
+	relaunches := 0
+
+	for {
+		var reason string
+		select {
+		case <-s.closed.Watch():
+			return
+		case reason = <-s.crashed:
+		}
+
+		logger.Warnw("chrome crashed", nil, "reason", reason)
+		s.monitor.IncChromeCrash(reason)
+		s.crashes.addCrash(reason)
+		if maxRelaunches > 0 {
+			s.callbacks.OnTrackMuted(config.WebVideoTrackID)
+		}
+
+		for {
+			if relaunches >= maxRelaunches {
+				logger.Warnw("chrome relaunch limit reached, ending recording", nil, "relaunches", relaunches)
+				s.signalEnd()
+				return
+			}
+			relaunches++
+
+			err := relaunch()
+			if s.closed.IsBroken() {
+				return
+			}
+			s.monitor.IncChromeRelaunch(err == nil)
+			if err == nil {
+				break
+			}
+			logger.Warnw("failed to relaunch chrome", err, "relaunches", relaunches)
+		}
+
+		logger.Infow("chrome relaunched", "relaunches", relaunches)
+		s.crashes.setRecovered()
+		if s.started() {
+			// otherwise unmuted once the relaunched page is ready
+			s.callbacks.OnTrackUnmuted(config.WebVideoTrackID)
+		}
+	}
+}
+
+func (s *WebSource) started() bool {
+	if s.startRecording == nil {
+		return true
+	}
+	select {
+	case <-s.startRecording:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *WebSource) relaunchChrome(
+	ctx context.Context,
+	p *config.PipelineConfig,
+	webUrl string,
+) error {
+	s.stopChrome()
+	if s.closed.IsBroken() {
+		return nil
+	}
+
+	return s.launchChromeWithTimeout(ctx, p, webUrl)
+}
+
+// stopChrome closes the running chrome, if any
+func (s *WebSource) stopChrome() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closeChrome != nil {
+		s.closeChrome()
+		s.closeChrome = nil
+	}
+}
+
+func (s *WebSource) signalEnd() {
+	s.endOnce.Do(func() {
+		close(s.endRecording)
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/egress/pkg/gstreamer"
+	"github.com/livekit/egress/pkg/stats"
+)
+
+func TestChromeCrashLog(t *testing.T) {
+	crashes := newChromeCrashLog()
+	require.Equal(t, "", crashes.summary())
+	crashes.addCrash(crashRendererCrashed)
+	crashes.setRecovered()
+	crashes.addCrash(crashProcessExited)
+	require.Equal(t, "chrome crashed 2 times (renderer_crashed, process_exited), recovered 1", crashes.summary())
+}
+
+func TestMonitorChrome(t *testing.T) {
+	var muted, unmuted atomic.Int32
+	callbacks := &gstreamer.Callbacks{}
+	callbacks.AddOnTrackMuted(func(string) { muted.Add(1) })
+	callbacks.AddOnTrackUnmuted(func(string) { unmuted.Add(1) })
+
+	s := &WebSource{
+		crashed:      make(chan string, 1),
+		crashes:      newChromeCrashLog(),
+		endRecording: make(chan struct{}),
+		callbacks:    callbacks,
+		monitor:      stats.NewHandlerMonitor("node", "cluster", "egress"),
+	}
+
+	relaunches := make(chan error)
+	done := make(chan struct{})
+	go func() {
+		s.monitorChrome(2, func() error { return <-relaunches })
+		close(done)
+	}()
+
+	// a successful relaunch switches back to the page
+	s.crashed <- crashRendererCrashed
+	relaunches <- nil
+	require.Eventually(t, func() bool { return unmuted.Load() == 1 }, time.Second, time.Millisecond*10)
+	require.Equal(t, int32(1), muted.Load())
+	require.Equal(t, "chrome crashed 1 time (renderer_crashed), recovered 1", s.CrashSummary())
+
+	// the recording ends once relaunches run out
+	s.crashed <- crashProcessExited
+	relaunches <- errors.New("page load failed")
+	select {
+	case <-s.EndRecording():
+	case <-time.After(time.Second):
+		t.Fatal("recording did not end")
+	}
+	<-done
+
+	require.Equal(t, int32(2), muted.Load())
+	require.Equal(t, int32(1), unmuted.Load())
+	require.Equal(t, "chrome crashed 2 times (renderer_crashed, process_exited), recovered 1", s.CrashSummary())
+}
@@ -0,0 +1,356 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/protocol/logger"
+)
+
+// storageScript runs before any page script in every frame of the first page load, seeding storage for the page origin
+const storageScript = `(() => {
+	const s = %s;
+	if (location.origin !== s.origin) return;
+	for (const [k, v] of Object.entries(s.local || {})) localStorage.setItem(k, v);
+	for (const [k, v] of Object.entries(s.session || {})) sessionStorage.setItem(k, v);
+})();`
+
+// stylesheetScript adds a style element to every document as soon as it has a root element
+const stylesheetScript = `(() => {
+	const css = %s;
+	const add = () => {
+		const style = document.createElement('style');
+		style.textContent = css;
+		(document.head || document.documentElement).appendChild(style);
+	};
+	if (document.documentElement) add();
+	else document.addEventListener('DOMContentLoaded', add);
+})();`
+
+// fillStylesheet lays the captured element out over the whole page
+const fillStylesheet = `%s {
+	position: fixed !important;
+	inset: 0 !important;
+	width: 100vw !important;
+	height: 100vh !important;
+	max-width: none !important;
+	max-height: none !important;
+	margin: 0 !important;
+	z-index: 2147483647 !important;
+}
+html, body { overflow: hidden !important; }`
+
+// measureScript scrolls the element into view and returns its bounding box
+const measureScript = `(() => {
+	const el = document.querySelector(%s);
+	el.scrollIntoView({block: 'nearest', inline: 'nearest'});
+	const r = el.getBoundingClientRect();
+	return [r.left, r.top, r.width, r.height];
+})()`
+
+const (
+	captureTimeout      = time.Second * 15
+	networkIdleTime     = time.Millisecond * 500
+	waitForPollInterval = time.Millisecond * 100
+)
+
+// applyWebOptions sets the request's headers, cookies and storage for the page. Values are never logged.
+// The returned func stops seeding storage, and is called once the page has loaded.
+func applyWebOptions(ctx context.Context, web *config.WebConfig, webUrl string) (func() error, error) {
+	stopSeeding := func() error { return nil }
+	if len(web.Headers) == 0 && len(web.Cookies) == 0 && len(web.LocalStorage) == 0 && len(web.SessionStorage) == 0 &&
+		web.Stylesheet == "" && web.Script == "" && (web.Capture == nil || !web.Capture.Fill) {
+		return stopSeeding, nil
+	}
+
+	u, err := url.Parse(webUrl)
+	if err != nil {
+		return nil, err
+	}
+	origin := getOrigin(u)
+
+	headerNames := make([]string, 0, len(web.Headers))
+	for name := range web.Headers {
+		headerNames = append(headerNames, name)
+	}
+	cookies := make([]*network.CookieParam, 0, len(web.Cookies))
+	cookieNames := make([]string, 0, len(web.Cookies))
+	for _, c := range web.Cookies {
+		cookie := &network.CookieParam{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HTTPOnly: c.HttpOnly,
+		}
+		if cookie.Domain == "" {
+			cookie.URL = webUrl
+		}
+		if cookie.Path == "" {
+			cookie.Path = "/"
+		}
+		cookies = append(cookies, cookie)
+		cookieNames = append(cookieNames, c.Name)
+	}
+
+	logger.Infow("applying web options",
+		"origin", origin,
+		"headers", headerNames,
+		"cookies", cookieNames,
+		"localStorageKeys", len(web.LocalStorage),
+		"sessionStorageKeys", len(web.SessionStorage),
+		"stylesheet", web.Stylesheet != "",
+		"script", web.Script != "",
+		"waitFor", web.WaitFor != nil,
+		"capture", web.Capture != nil,
+	)
+
+	if len(web.Headers) > 0 {
+		if err = interceptHeaders(ctx, origin, web.Headers); err != nil {
+			return nil, err
+		}
+	}
+	if len(cookies) > 0 {
+		if err = chromedp.Run(ctx, network.SetCookies(cookies)); err != nil {
+			return nil, err
+		}
+	}
+
+	var scripts []string
+	if web.Stylesheet != "" {
+		b, err := json.Marshal(web.Stylesheet)
+		if err != nil {
+			return nil, err
+		}
+		scripts = append(scripts, fmt.Sprintf(stylesheetScript, b))
+	}
+	if web.Script != "" {
+		scripts = append(scripts, web.Script)
+	}
+	if capture := web.Capture; capture != nil && capture.Fill {
+		b, err := json.Marshal(fmt.Sprintf(fillStylesheet, capture.Selector))
+		if err != nil {
+			return nil, err
+		}
+		scripts = append(scripts, fmt.Sprintf(stylesheetScript, b))
+	}
+	if err = addScripts(ctx, scripts); err != nil {
+		return nil, err
+	}
+
+	if len(web.LocalStorage) > 0 || len(web.SessionStorage) > 0 {
+		b, err := json.Marshal(map[string]interface{}{
+			"origin":  origin,
+			"local":   web.LocalStorage,
+			"session": web.SessionStorage,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var id page.ScriptIdentifier
+		if err = chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			id, err = page.AddScriptToEvaluateOnNewDocument(fmt.Sprintf(storageScript, b)).Do(ctx)
+			return err
+		})); err != nil {
+			return nil, err
+		}
+
+		// storage persists across documents, so values changed by the page are not reset on reload
+		stopSeeding = func() error {
+			return chromedp.Run(ctx, page.RemoveScriptToEvaluateOnNewDocument(id))
+		}
+	}
+
+	return stopSeeding, nil
+}
+
+// interceptHeaders adds headers to requests for the page origin only, so they are not sent to third parties
+func interceptHeaders(ctx context.Context, origin string, headers map[string]string) error {
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		if ev, ok := ev.(*fetch.EventRequestPaused); ok {
+			// cdp calls can't be made from the listener
+			go func() {
+				req := fetch.ContinueRequest(ev.RequestID)
+				if u, err := url.Parse(ev.Request.URL); err == nil && getOrigin(u) == origin {
+					req = req.WithHeaders(mergeHeaders(ev.Request.Headers, headers))
+				}
+				if err := chromedp.Run(ctx, req); err != nil && ctx.Err() == nil {
+					logger.Debugw("failed to continue request", "error", err)
+				}
+			}()
+		}
+	})
+
+	return chromedp.Run(ctx, fetch.Enable().WithPatterns([]*fetch.RequestPattern{{URLPattern: origin + "/*"}}))
+}
+
+func getOrigin(u *url.URL) string {
+	return fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+}
+
+// mergeHeaders replaces request headers with the same name, ignoring case
+func mergeHeaders(request network.Headers, headers map[string]string) []*fetch.HeaderEntry {
+	entries := make([]*fetch.HeaderEntry, 0, len(request)+len(headers))
+	for name, value := range request {
+		replaced := false
+		for extra := range headers {
+			if strings.EqualFold(name, extra) {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			entries = append(entries, &fetch.HeaderEntry{Name: name, Value: fmt.Sprint(value)})
+		}
+	}
+	for name, value := range headers {
+		entries = append(entries, &fetch.HeaderEntry{Name: name, Value: value})
+	}
+	return entries
+}
+
+// addScripts evaluates each script in every document, before page scripts
+func addScripts(ctx context.Context, scripts []string) error {
+	var actions []chromedp.Action
+	for _, script := range scripts {
+		actions = append(actions, chromedp.ActionFunc(func(ctx context.Context) error {
+			_, err := page.AddScriptToEvaluateOnNewDocument(script).Do(ctx)
+			return err
+		}))
+	}
+	return chromedp.Run(ctx, actions...)
+}
+
+// waitForPage blocks until the condition is satisfied or has timed out
+func waitForPage(ctx context.Context, w *config.WebWaitCondition, requests *networkTracker) {
+	waitCtx, cancel := context.WithTimeout(ctx, w.GetTimeout())
+	defer cancel()
+
+	if err := waitForCondition(waitCtx, w, requests); err != nil && ctx.Err() == nil {
+		logger.Warnw("web wait condition not satisfied, continuing", err,
+			"selector", w.Selector,
+			"expression", w.Expression != "",
+			"networkIdle", w.NetworkIdle,
+		)
+	}
+}
+
+func waitForCondition(ctx context.Context, w *config.WebWaitCondition, requests *networkTracker) error {
+	if w.Selector != "" {
+		if err := chromedp.Run(ctx, chromedp.WaitVisible(w.Selector, chromedp.ByQuery)); err != nil {
+			return err
+		}
+	}
+	if w.Expression != "" {
+		var res interface{}
+		if err := chromedp.Run(ctx, chromedp.Poll(w.Expression, &res, chromedp.WithPollingInterval(waitForPollInterval))); err != nil {
+			return err
+		}
+	}
+	if w.NetworkIdle {
+		ticker := time.NewTicker(waitForPollInterval)
+		defer ticker.Stop()
+		for !requests.idle(networkIdleTime) {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	}
+	return nil
+}
+
+// networkTracker counts requests in flight, fed by network events
+type networkTracker struct {
+	mu           sync.Mutex
+	inflight     map[network.RequestID]struct{}
+	lastActivity time.Time
+}
+
+func newNetworkTracker() *networkTracker {
+	return &networkTracker{
+		inflight:     make(map[network.RequestID]struct{}),
+		lastActivity: time.Now(),
+	}
+}
+
+func (t *networkTracker) handleEvent(ev interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch ev := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		t.inflight[ev.RequestID] = struct{}{}
+	case *network.EventLoadingFinished:
+		delete(t.inflight, ev.RequestID)
+	case *network.EventLoadingFailed:
+		delete(t.inflight, ev.RequestID)
+	default:
+		return
+	}
+	t.lastActivity = time.Now()
+}
+
+func (t *networkTracker) idle(d time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.inflight) == 0 && time.Since(t.lastActivity) >= d
+}
+
+// measureElement waits for the element to be visible and returns its bounding box in page pixels
+func measureElement(ctx context.Context, selector string) (x, y, width, height int32, err error) {
+	ctx, cancel := context.WithTimeout(ctx, captureTimeout)
+	defer cancel()
+
+	b, err := json.Marshal(selector)
+	if err != nil {
+		return
+	}
+
+	var rect []float64
+	if err = chromedp.Run(ctx,
+		chromedp.WaitVisible(selector, chromedp.ByQuery),
+		chromedp.Evaluate(fmt.Sprintf(measureScript, b), &rect),
+	); err != nil {
+		return
+	}
+	if len(rect) != 4 {
+		err = fmt.Errorf("unexpected bounding box %v", rect)
+		return
+	}
+
+	x, y = int32(math.Round(rect[0])), int32(math.Round(rect[1]))
+	width, height = int32(math.Round(rect[2])), int32(math.Round(rect[3]))
+	return
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeHeaders(t *testing.T) {
+	entries := mergeHeaders(
+		network.Headers{"Accept": "text/html", "authorization": "Basic old"},
+		map[string]string{"Authorization": "Bearer token"},
+	)
+	require.ElementsMatch(t, []*fetch.HeaderEntry{
+		{Name: "Accept", Value: "text/html"},
+		{Name: "Authorization", Value: "Bearer token"},
+	}, entries)
+}
+
+func TestGetOrigin(t *testing.T) {
+	for rawUrl, expected := range map[string]string{
+		"https://dashboard.example.com/live?tab=2":   "https://dashboard.example.com",
+		"http://localhost:8080/":                     "http://localhost:8080",
+		"https://dashboard.example.com.evil.com/app": "https://dashboard.example.com.evil.com",
+	} {
+		u, err := url.Parse(rawUrl)
+		require.NoError(t, err)
+		require.Equal(t, expected, getOrigin(u))
+	}
+}
@@ -17,7 +17,9 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"math"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -248,6 +250,8 @@ const (
 	msgFragmentOpened      = "splitmuxsink-fragment-opened"
 	msgFragmentClosed      = "splitmuxsink-fragment-closed"
 	msgGstMultiFileSink    = "GstMultiFileSink"
+	msgLoudness            = "ebur128-level"
+	msgLevel               = "level"
 )
 
 func (c *Controller) handleMessageElement(msg *gst.Message) error {
@@ -306,6 +310,24 @@ func (c *Controller) handleMessageElement(msg *gst.Message) error {
 			if err != nil {
 				return err
 			}
+
+		case msgLevel:
+			if c.Speakers == nil {
+				break
+			}
+			level, runningTime, err := getLevelFromGstStructure(s)
+			if err != nil {
+				logger.Debugw("failed to parse level message", err)
+				break
+			}
+			c.Speakers.UpdateLevel(msg.Source(), level, runningTime)
+
+		case msgLoudness:
+			if loudness, err := s.GetValue(globalLoudness); err == nil {
+				if l, ok := loudness.(float64); ok {
+					c.IntegratedLoudness.Store(l)
+				}
+			}
 		}
 	}
 
@@ -326,6 +348,9 @@ func parseDebugInfo(gErr *gst.GError) (element, name, message string) {
 }
 
 const (
+	globalLoudness   = "global-loudness"
+	levelRunningTime = "running-time"
+
 	fragmentLocation    = "location"
 	fragmentRunningTime = "running-time"
 )
@@ -390,6 +415,35 @@ func getImageInformationFromGstStructure(s *gst.Structure) (string, uint64, erro
 
 }
 
+// rms is a list of per-channel values, serialized as (GValueArray)< -20.1, -21.5 > or (double){ -20.1, -21.5 }
+var levelRMS = regexp.MustCompile(`rms=\([A-Za-z]+\)[<{]([^>}]*)[>}]`)
+
+// getLevelFromGstStructure returns the loudest channel rms in dBFS
+func getLevelFromGstStructure(s *gst.Structure) (float64, time.Duration, error) {
+	t, err := s.GetValue(levelRunningTime)
+	if err != nil {
+		return 0, 0, err
+	}
+	runningTime, ok := t.(uint64)
+	if !ok {
+		return 0, 0, errors.ErrGstPipelineError(errors.New("invalid type for running-time"))
+	}
+
+	match := levelRMS.FindStringSubmatch(s.String())
+	if match == nil {
+		return 0, 0, errors.ErrGstPipelineError(errors.New("missing rms"))
+	}
+
+	level := math.Inf(-1)
+	for _, v := range strings.Split(match[1], ",") {
+		if rms, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			level = math.Max(level, rms)
+		}
+	}
+
+	return level, time.Duration(runningTime), nil
+}
+
 func (c *Controller) getSegmentSink() *sink.SegmentSink {
 	s := c.sinks[types.EgressTypeSegments]
 	if len(s) == 0 {
@@ -50,6 +50,7 @@ type Server struct {
 	monitor *stats.Monitor
 
 	psrpcServer      rpc.EgressInternalServer
+	snapshotServer   *ipc.SnapshotServer
 	ipcServiceServer *grpc.Server
 	promServer       *http.Server
 	ioClient         info.IOClient
@@ -114,6 +115,7 @@ func NewServer(conf *config.ServiceConfig, bus psrpc.MessageBus, ioClient info.I
 		return nil, err
 	}
 	s.psrpcServer = psrpcServer
+	s.snapshotServer = ipc.NewSnapshotServer(bus)
 
 	return s, nil
 }
@@ -193,6 +195,7 @@ func (s *Server) Drain() {
 	}
 
 	s.psrpcServer.Shutdown()
+	s.snapshotServer.Shutdown()
 	logger.Infow("draining io client")
 	s.ioClient.Drain()
 }
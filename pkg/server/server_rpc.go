@@ -28,6 +28,7 @@ import (
 
 	"github.com/livekit/egress/pkg/config"
 	"github.com/livekit/egress/pkg/errors"
+	"github.com/livekit/egress/pkg/ipc"
 	"github.com/livekit/protocol/egress"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
@@ -128,6 +129,9 @@ func (s *Server) launchProcess(req *rpc.StartEgressRequest, info *livekit.Egress
 		s.processEnded(req, info, err)
 	} else {
 		s.monitor.UpdatePID(info.EgressId, cmd.Process.Pid)
+		if err = s.snapshotServer.RegisterEgress(info.EgressId, s.getSnapshot(info.EgressId)); err != nil {
+			logger.Warnw("failed to register snapshot topic", err, "egressID", info.EgressId)
+		}
 		go func() {
 			err = cmd.Wait()
 			s.processEnded(req, info, err)
@@ -158,10 +162,22 @@ func (s *Server) processEnded(req *rpc.StartEgressRequest, info *livekit.EgressI
 		})
 	}
 
+	s.snapshotServer.DeregisterEgress(info.EgressId)
 	s.ProcessFinished(info.EgressId)
 	s.activeRequests.Dec()
 }
 
+// getSnapshot passes snapshot requests for the egress on to its handler
+func (s *Server) getSnapshot(egressID string) func(context.Context, *ipc.SnapshotRequest) (*ipc.SnapshotResponse, error) {
+	return func(ctx context.Context, req *ipc.SnapshotRequest) (*ipc.SnapshotResponse, error) {
+		c, err := s.GetGRPCClient(egressID)
+		if err != nil {
+			return nil, err
+		}
+		return c.GetSnapshot(ctx, req)
+	}
+}
+
 func (s *Server) StartEgressAffinity(_ context.Context, req *rpc.StartEgressRequest) float32 {
 	if s.IsDisabled() || !s.monitor.CanAcceptRequest(req) {
 		// cannot accept
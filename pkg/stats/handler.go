@@ -22,6 +22,8 @@ type HandlerMonitor struct {
 	uploadsCounter      *prometheus.CounterVec
 	uploadsResponseTime *prometheus.HistogramVec
 	backupCounter       *prometheus.CounterVec
+	chromeCrashCounter  *prometheus.CounterVec
+	chromeRelaunches    *prometheus.CounterVec
 }
 
 func NewHandlerMonitor(nodeId string, clusterId string, egressId string) *HandlerMonitor {
@@ -54,7 +56,23 @@ func NewHandlerMonitor(nodeId string, clusterId string, egressId string) *Handle
 		ConstLabels: constantLabels,
 	}, []string{"output_type"})
 
-	prometheus.MustRegister(m.uploadsCounter, m.uploadsResponseTime, m.backupCounter)
+	m.chromeCrashCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   "livekit",
+		Subsystem:   "egress",
+		Name:        "chrome_crashes",
+		Help:        "number of chrome crashes during web egress by reason",
+		ConstLabels: constantLabels,
+	}, []string{"reason"})
+
+	m.chromeRelaunches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   "livekit",
+		Subsystem:   "egress",
+		Name:        "chrome_relaunches",
+		Help:        "number of chrome relaunches after a crash with status label",
+		ConstLabels: constantLabels,
+	}, []string{"status"}) // status: success, failure
+
+	prometheus.MustRegister(m.uploadsCounter, m.uploadsResponseTime, m.backupCounter, m.chromeCrashCounter, m.chromeRelaunches)
 
 	return m
 }
@@ -75,6 +93,18 @@ func (m *HandlerMonitor) IncBackupStorageWrites(outputType string) {
 	m.backupCounter.With(prometheus.Labels{"output_type": outputType}).Add(1)
 }
 
+func (m *HandlerMonitor) IncChromeCrash(reason string) {
+	m.chromeCrashCounter.With(prometheus.Labels{"reason": reason}).Add(1)
+}
+
+func (m *HandlerMonitor) IncChromeRelaunch(success bool) {
+	status := "success"
+	if !success {
+		status = "failure"
+	}
+	m.chromeRelaunches.With(prometheus.Labels{"status": status}).Add(1)
+}
+
 func (m *HandlerMonitor) RegisterSegmentsChannelSizeGauge(nodeId string, clusterId string, egressId string, channelSizeFunction func() float64) {
 	segmentsUploadsGauge := prometheus.NewGaugeFunc(
 		prometheus.GaugeOpts{
@@ -48,6 +48,7 @@ type Monitor struct {
 	nodeID        string
 	clusterID     string
 	cpuCostConfig *config.CPUCostConfig
+	baseConfig    *config.BaseConfig
 
 	promCPULoad  prometheus.Gauge
 	requestGauge *prometheus.GaugeVec
@@ -80,6 +81,7 @@ func NewMonitor(conf *config.ServiceConfig, svc Service) (*Monitor, error) {
 		nodeID:        conf.NodeID,
 		clusterID:     conf.ClusterID,
 		cpuCostConfig: conf.CPUCostConfig,
+		baseConfig:    &conf.BaseConfig,
 		svc:           svc,
 		pending:       make(map[string]*processStats),
 		procStats:     make(map[int]*processStats),
@@ -104,6 +106,8 @@ func (m *Monitor) validateCPUConfig() error {
 	requirements := []float64{
 		m.cpuCostConfig.RoomCompositeCpuCost,
 		m.cpuCostConfig.AudioRoomCompositeCpuCost,
+		m.cpuCostConfig.SDKRoomCompositeCpuCost,
+		m.cpuCostConfig.SDKAudioRoomCompositeCpuCost,
 		m.cpuCostConfig.WebCpuCost,
 		m.cpuCostConfig.AudioWebCpuCost,
 		m.cpuCostConfig.ParticipantCpuCost,
@@ -163,35 +167,11 @@ func (m *Monitor) canAcceptRequestLocked(req *rpc.StartEgressRequest) ([]interfa
 		"activeWeb", m.webRequests.Load(),
 	}
 
-	var accept bool
-	var required float64
-	switch r := req.Request.(type) {
-	case *rpc.StartEgressRequest_RoomComposite:
-		if m.webRequests.Load() >= m.cpuCostConfig.MaxConcurrentWeb {
-			return fields, false
-		}
-		if r.RoomComposite.AudioOnly {
-			required = m.cpuCostConfig.AudioRoomCompositeCpuCost
-		} else {
-			required = m.cpuCostConfig.RoomCompositeCpuCost
-		}
-	case *rpc.StartEgressRequest_Web:
-		if m.webRequests.Load() >= m.cpuCostConfig.MaxConcurrentWeb {
-			return fields, false
-		}
-		if r.Web.AudioOnly {
-			required = m.cpuCostConfig.AudioWebCpuCost
-		} else {
-			required = m.cpuCostConfig.WebCpuCost
-		}
-	case *rpc.StartEgressRequest_Participant:
-		required = m.cpuCostConfig.ParticipantCpuCost
-	case *rpc.StartEgressRequest_TrackComposite:
-		required = m.cpuCostConfig.TrackCompositeCpuCost
-	case *rpc.StartEgressRequest_Track:
-		required = m.cpuCostConfig.TrackCpuCost
+	required, web := m.getRequestCost(req)
+	if web && m.webRequests.Load() >= m.cpuCostConfig.MaxConcurrentWeb {
+		return fields, false
 	}
-	accept = available >= required
+	accept := available >= required
 
 	fields = append(fields,
 		"required", required,
@@ -214,28 +194,9 @@ func (m *Monitor) AcceptRequest(req *rpc.StartEgressRequest) error {
 	}
 
 	m.requests.Inc()
-	var cpuHold float64
-	switch r := req.Request.(type) {
-	case *rpc.StartEgressRequest_RoomComposite:
-		m.webRequests.Inc()
-		if r.RoomComposite.AudioOnly {
-			cpuHold = m.cpuCostConfig.AudioRoomCompositeCpuCost
-		} else {
-			cpuHold = m.cpuCostConfig.RoomCompositeCpuCost
-		}
-	case *rpc.StartEgressRequest_Web:
+	cpuHold, web := m.getRequestCost(req)
+	if web {
 		m.webRequests.Inc()
-		if r.Web.AudioOnly {
-			cpuHold = m.cpuCostConfig.AudioWebCpuCost
-		} else {
-			cpuHold = m.cpuCostConfig.WebCpuCost
-		}
-	case *rpc.StartEgressRequest_Participant:
-		cpuHold = m.cpuCostConfig.ParticipantCpuCost
-	case *rpc.StartEgressRequest_TrackComposite:
-		cpuHold = m.cpuCostConfig.TrackCompositeCpuCost
-	case *rpc.StartEgressRequest_Track:
-		cpuHold = m.cpuCostConfig.TrackCpuCost
 	}
 
 	ps := &processStats{
@@ -278,8 +239,7 @@ func (m *Monitor) EgressAborted(req *rpc.StartEgressRequest) {
 
 	delete(m.pending, req.EgressId)
 	m.requests.Dec()
-	switch req.Request.(type) {
-	case *rpc.StartEgressRequest_RoomComposite, *rpc.StartEgressRequest_Web:
+	if _, web := m.getRequestCost(req); web {
 		m.webRequests.Dec()
 	}
 }
@@ -306,10 +266,8 @@ func (m *Monitor) EgressEnded(req *rpc.StartEgressRequest) (float64, float64) {
 	switch req.Request.(type) {
 	case *rpc.StartEgressRequest_RoomComposite:
 		m.requestGauge.With(prometheus.Labels{"type": types.RequestTypeRoomComposite}).Sub(1)
-		m.webRequests.Dec()
 	case *rpc.StartEgressRequest_Web:
 		m.requestGauge.With(prometheus.Labels{"type": types.RequestTypeWeb}).Sub(1)
-		m.webRequests.Dec()
 	case *rpc.StartEgressRequest_Participant:
 		m.requestGauge.With(prometheus.Labels{"type": types.RequestTypeParticipant}).Sub(1)
 	case *rpc.StartEgressRequest_TrackComposite:
@@ -320,6 +278,9 @@ func (m *Monitor) EgressEnded(req *rpc.StartEgressRequest) (float64, float64) {
 
 	delete(m.pending, req.EgressId)
 	m.requests.Dec()
+	if _, web := m.getRequestCost(req); web {
+		m.webRequests.Dec()
+	}
 
 	for pid, ps := range m.procStats {
 		if ps.egressID == req.EgressId {
@@ -331,6 +292,37 @@ func (m *Monitor) EgressEnded(req *rpc.StartEgressRequest) (float64, float64) {
 	return 0, 0
 }
 
+// getRequestCost returns the cpu cost of a request, and whether it requires a web instance
+func (m *Monitor) getRequestCost(req *rpc.StartEgressRequest) (float64, bool) {
+	switch r := req.Request.(type) {
+	case *rpc.StartEgressRequest_RoomComposite:
+		sdk := m.baseConfig.GetRoomCompositeSourceType(r.RoomComposite) == types.SourceTypeSDK
+		switch {
+		case sdk && r.RoomComposite.AudioOnly:
+			return m.cpuCostConfig.SDKAudioRoomCompositeCpuCost, false
+		case sdk:
+			return m.cpuCostConfig.SDKRoomCompositeCpuCost, false
+		case r.RoomComposite.AudioOnly:
+			return m.cpuCostConfig.AudioRoomCompositeCpuCost, true
+		default:
+			return m.cpuCostConfig.RoomCompositeCpuCost, true
+		}
+	case *rpc.StartEgressRequest_Web:
+		if r.Web.AudioOnly {
+			return m.cpuCostConfig.AudioWebCpuCost, true
+		}
+		return m.cpuCostConfig.WebCpuCost, true
+	case *rpc.StartEgressRequest_Participant:
+		return m.cpuCostConfig.ParticipantCpuCost, false
+	case *rpc.StartEgressRequest_TrackComposite:
+		return m.cpuCostConfig.TrackCompositeCpuCost, false
+	case *rpc.StartEgressRequest_Track:
+		return m.cpuCostConfig.TrackCpuCost, false
+	default:
+		return 0, false
+	}
+}
+
 func (m *Monitor) GetAvailableCPU() float64 {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -0,0 +1,61 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/egress/pkg/config"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+)
+
+func TestGetRequestCost(t *testing.T) {
+	m := &Monitor{
+		cpuCostConfig: &config.CPUCostConfig{
+			RoomCompositeCpuCost:    4,
+			SDKRoomCompositeCpuCost: 2,
+		},
+		baseConfig: &config.BaseConfig{EnableSDKRoomComposite: true},
+	}
+
+	for _, test := range []struct {
+		name          string
+		customBaseUrl string
+		expectedCost  float64
+		expectedWeb   bool
+	}{
+		{name: "native layout", expectedCost: 2},
+		{name: "options only", customBaseUrl: "#lk_egress=" + url.PathEscape(`{"speaking_timeline":true}`), expectedCost: 2},
+		{name: "custom base url", customBaseUrl: "https://example.com#lk_egress=" + url.PathEscape(`{"speaking_timeline":true}`), expectedCost: 4, expectedWeb: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cost, web := m.getRequestCost(&rpc.StartEgressRequest{
+				Request: &rpc.StartEgressRequest_RoomComposite{
+					RoomComposite: &livekit.RoomCompositeEgressRequest{
+						RoomName:      "room",
+						Layout:        "native-grid",
+						CustomBaseUrl: test.customBaseUrl,
+					},
+				},
+			})
+			require.Equal(t, test.expectedCost, cost)
+			require.Equal(t, test.expectedWeb, web)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/egress/pkg/config"
+)
+
+// trackQualityCollector reads the current stats of each track when scraped
+type trackQualityCollector struct {
+	report     *config.QualityReport
+	avgDrift   *prometheus.Desc
+	maxDrift   *prometheus.Desc
+	packetLoss *prometheus.Desc
+}
+
+func (m *HandlerMonitor) RegisterTrackQuality(nodeId string, clusterId string, egressId string, report *config.QualityReport) {
+	constantLabels := prometheus.Labels{"node_id": nodeId, "cluster_id": clusterId, "egress_id": egressId}
+	labels := []string{"track_id", "kind"}
+
+	prometheus.MustRegister(&trackQualityCollector{
+		report: report,
+		avgDrift: prometheus.NewDesc(
+			"livekit_egress_track_avg_drift_seconds",
+			"moving average of the a/v sync correction applied to a track",
+			labels, constantLabels,
+		),
+		maxDrift: prometheus.NewDesc(
+			"livekit_egress_track_max_drift_seconds",
+			"largest a/v sync correction applied to a track",
+			labels, constantLabels,
+		),
+		packetLoss: prometheus.NewDesc(
+			"livekit_egress_track_packet_loss_ratio",
+			"fraction of track packets dropped by the jitter buffer",
+			labels, constantLabels,
+		),
+	})
+}
+
+func (c *trackQualityCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.avgDrift
+	ch <- c.maxDrift
+	ch <- c.packetLoss
+}
+
+func (c *trackQualityCollector) Collect(ch chan<- prometheus.Metric) {
+	// a resubscribed track replaces its earlier stats
+	tracks := make(map[string]config.TrackQuality)
+	for _, t := range c.report.Tracks() {
+		tracks[t.TrackID] = t
+	}
+
+	for _, t := range tracks {
+		ch <- prometheus.MustNewConstMetric(c.avgDrift, prometheus.GaugeValue, t.AvgDrift.Seconds(), t.TrackID, t.Kind)
+		ch <- prometheus.MustNewConstMetric(c.maxDrift, prometheus.GaugeValue, t.MaxDrift.Seconds(), t.TrackID, t.Kind)
+		ch <- prometheus.MustNewConstMetric(c.packetLoss, prometheus.GaugeValue, t.PacketLoss, t.TrackID, t.Kind)
+	}
+}
@@ -34,6 +34,11 @@ const (
 	SourceTypeWeb SourceType = "web"
 	SourceTypeSDK SourceType = "sdk"
 
+	// sdk room composite layouts
+	LayoutGrid          = "grid"
+	LayoutSpeaker       = "speaker"
+	LayoutSingleSpeaker = "single-speaker"
+
 	// egress types
 	EgressTypeStream    EgressType = "stream"
 	EgressTypeWebsocket EgressType = "websocket"
@@ -45,10 +50,16 @@ const (
 	MimeTypeAAC      MimeType = "audio/aac"
 	MimeTypeOpus     MimeType = "audio/opus"
 	MimeTypeRawAudio MimeType = "audio/x-raw"
+	MimeTypeFLAC     MimeType = "audio/flac"
+	MimeTypeMP3      MimeType = "audio/mpeg"
 	MimeTypeH264     MimeType = "video/h264"
+	MimeTypeH265     MimeType = "video/h265"
 	MimeTypeVP8      MimeType = "video/vp8"
 	MimeTypeVP9      MimeType = "video/vp9"
+	MimeTypeAV1      MimeType = "video/av1"
 	MimeTypeJPEG     MimeType = "image/jpeg"
+	MimeTypePNG      MimeType = "image/png"
+	MimeTypeWebP     MimeType = "image/webp"
 	MimeTypeRawVideo MimeType = "video/x-raw"
 
 	// video profiles
@@ -60,35 +71,60 @@ const (
 	OutputTypeUnknownFile OutputType = ""
 	OutputTypeRaw         OutputType = "audio/x-raw"
 	OutputTypeOGG         OutputType = "audio/ogg"
+	OutputTypeWAV         OutputType = "audio/wav"
+	OutputTypeFLAC        OutputType = "audio/flac"
+	OutputTypeMP3         OutputType = "audio/mpeg"
 	OutputTypeIVF         OutputType = "video/x-ivf"
 	OutputTypeMP4         OutputType = "video/mp4"
 	OutputTypeTS          OutputType = "video/mp2t"
+	OutputTypeM4S         OutputType = "video/iso.segment"
 	OutputTypeWebM        OutputType = "video/webm"
+	OutputTypeMKV         OutputType = "video/x-matroska"
 	OutputTypeJPEG        OutputType = "image/jpeg"
+	OutputTypePNG         OutputType = "image/png"
+	OutputTypeWebP        OutputType = "image/webp"
+	OutputTypeGIF         OutputType = "image/gif"
 	OutputTypeRTMP        OutputType = "rtmp"
 	OutputTypeSRT         OutputType = "srt"
 	OutputTypeHLS         OutputType = "application/x-mpegurl"
 	OutputTypeJSON        OutputType = "application/json"
+	OutputTypeVTT         OutputType = "text/vtt"
+	OutputTypeJSONL       OutputType = "application/jsonl"
+	OutputTypeSRTCaptions OutputType = "application/x-subrip"
 	OutputTypeBlob        OutputType = "application/octet-stream"
 
 	// file extensions
 	FileExtensionRaw  = ".raw"
 	FileExtensionOGG  = ".ogg"
+	FileExtensionWAV  = ".wav"
+	FileExtensionFLAC = ".flac"
+	FileExtensionMP3  = ".mp3"
 	FileExtensionIVF  = ".ivf"
 	FileExtensionMP4  = ".mp4"
+	FileExtensionM4A  = ".m4a"
 	FileExtensionTS   = ".ts"
+	FileExtensionM4S  = ".m4s"
 	FileExtensionWebM = ".webm"
+	FileExtensionMKV  = ".mkv"
 	FileExtensionM3U8 = ".m3u8"
 	FileExtensionJPEG = ".jpeg"
+	FileExtensionJPG  = ".jpg"
+	FileExtensionPNG  = ".png"
+	FileExtensionWebP = ".webp"
+	FileExtensionGIF  = ".gif"
 )
 
 var (
 	DefaultAudioCodecs = map[OutputType]MimeType{
 		OutputTypeRaw:  MimeTypeRawAudio,
 		OutputTypeOGG:  MimeTypeOpus,
+		OutputTypeWAV:  MimeTypeRawAudio,
+		OutputTypeFLAC: MimeTypeFLAC,
+		OutputTypeMP3:  MimeTypeMP3,
 		OutputTypeMP4:  MimeTypeAAC,
 		OutputTypeTS:   MimeTypeAAC,
 		OutputTypeWebM: MimeTypeOpus,
+		OutputTypeMKV:  MimeTypeOpus,
 		OutputTypeRTMP: MimeTypeAAC,
 		OutputTypeSRT:  MimeTypeAAC,
 		OutputTypeHLS:  MimeTypeAAC,
@@ -99,6 +135,7 @@ var (
 		OutputTypeMP4:  MimeTypeH264,
 		OutputTypeTS:   MimeTypeH264,
 		OutputTypeWebM: MimeTypeVP8,
+		OutputTypeMKV:  MimeTypeH264,
 		OutputTypeRTMP: MimeTypeH264,
 		OutputTypeSRT:  MimeTypeH264,
 		OutputTypeHLS:  MimeTypeH264,
@@ -107,23 +144,61 @@ var (
 	FileExtensions = map[FileExtension]struct{}{
 		FileExtensionRaw:  {},
 		FileExtensionOGG:  {},
+		FileExtensionWAV:  {},
+		FileExtensionFLAC: {},
+		FileExtensionMP3:  {},
 		FileExtensionIVF:  {},
 		FileExtensionMP4:  {},
+		FileExtensionM4A:  {},
 		FileExtensionTS:   {},
 		FileExtensionWebM: {},
+		FileExtensionMKV:  {},
 		FileExtensionM3U8: {},
 		FileExtensionJPEG: {},
+		FileExtensionJPG:  {},
+		FileExtensionPNG:  {},
+		FileExtensionWebP: {},
+		FileExtensionGIF:  {},
 	}
 
 	FileExtensionForOutputType = map[OutputType]FileExtension{
 		OutputTypeRaw:  FileExtensionRaw,
 		OutputTypeOGG:  FileExtensionOGG,
+		OutputTypeWAV:  FileExtensionWAV,
+		OutputTypeFLAC: FileExtensionFLAC,
+		OutputTypeMP3:  FileExtensionMP3,
 		OutputTypeIVF:  FileExtensionIVF,
 		OutputTypeMP4:  FileExtensionMP4,
 		OutputTypeTS:   FileExtensionTS,
+		OutputTypeM4S:  FileExtensionM4S,
 		OutputTypeWebM: FileExtensionWebM,
+		OutputTypeMKV:  FileExtensionMKV,
 		OutputTypeHLS:  FileExtensionM3U8,
 		OutputTypeJPEG: FileExtensionJPEG,
+		OutputTypePNG:  FileExtensionPNG,
+		OutputTypeWebP: FileExtensionWebP,
+		OutputTypeGIF:  FileExtensionGIF,
+	}
+
+	// image codecs which can be requested by filename prefix extension
+	ImageCodecForFileExtension = map[FileExtension]MimeType{
+		FileExtensionJPEG: MimeTypeJPEG,
+		FileExtensionJPG:  MimeTypeJPEG,
+		FileExtensionPNG:  MimeTypePNG,
+		FileExtensionWebP: MimeTypeWebP,
+	}
+
+	// file types which can be requested by extension
+	OutputTypeForFileExtension = map[FileExtension]OutputType{
+		FileExtensionOGG:  OutputTypeOGG,
+		FileExtensionWAV:  OutputTypeWAV,
+		FileExtensionFLAC: OutputTypeFLAC,
+		FileExtensionMP3:  OutputTypeMP3,
+		FileExtensionIVF:  OutputTypeIVF,
+		FileExtensionMP4:  OutputTypeMP4,
+		FileExtensionM4A:  OutputTypeMP4,
+		FileExtensionWebM: OutputTypeWebM,
+		FileExtensionMKV:  OutputTypeMKV,
 	}
 
 	CodecCompatibility = map[OutputType]map[MimeType]bool{
@@ -133,6 +208,15 @@ var (
 		OutputTypeOGG: {
 			MimeTypeOpus: true,
 		},
+		OutputTypeWAV: {
+			MimeTypeRawAudio: true,
+		},
+		OutputTypeFLAC: {
+			MimeTypeFLAC: true,
+		},
+		OutputTypeMP3: {
+			MimeTypeMP3: true,
+		},
 		OutputTypeIVF: {
 			MimeTypeVP8: true,
 			MimeTypeVP9: true,
@@ -141,16 +225,28 @@ var (
 			MimeTypeAAC:  true,
 			MimeTypeOpus: true,
 			MimeTypeH264: true,
+			MimeTypeH265: true,
 		},
 		OutputTypeTS: {
 			MimeTypeAAC:  true,
 			MimeTypeOpus: true,
 			MimeTypeH264: true,
+			MimeTypeH265: true,
 		},
 		OutputTypeWebM: {
 			MimeTypeOpus: true,
 			MimeTypeVP8:  true,
 			MimeTypeVP9:  true,
+			MimeTypeAV1:  true,
+		},
+		OutputTypeMKV: {
+			MimeTypeAAC:  true,
+			MimeTypeOpus: true,
+			MimeTypeH264: true,
+			MimeTypeH265: true,
+			MimeTypeVP8:  true,
+			MimeTypeVP9:  true,
+			MimeTypeAV1:  true,
 		},
 		OutputTypeRTMP: {
 			MimeTypeAAC:  true,
@@ -159,17 +255,24 @@ var (
 		OutputTypeSRT: {
 			MimeTypeAAC:  true,
 			MimeTypeH264: true,
+			MimeTypeH265: true,
 		},
 		OutputTypeHLS: {
 			MimeTypeAAC:  true,
 			MimeTypeH264: true,
+			MimeTypeH265: true,
 		},
 		OutputTypeUnknownFile: {
-			MimeTypeAAC:  true,
-			MimeTypeOpus: true,
-			MimeTypeH264: true,
-			MimeTypeVP8:  true,
-			MimeTypeVP9:  true,
+			MimeTypeAAC:      true,
+			MimeTypeOpus:     true,
+			MimeTypeRawAudio: true,
+			MimeTypeFLAC:     true,
+			MimeTypeMP3:      true,
+			MimeTypeH264:     true,
+			MimeTypeH265:     true,
+			MimeTypeVP8:      true,
+			MimeTypeVP9:      true,
+			MimeTypeAV1:      true,
 		},
 	}
 
@@ -177,21 +280,37 @@ var (
 		MimeTypeAAC:      true,
 		MimeTypeOpus:     true,
 		MimeTypeRawAudio: true,
+		MimeTypeFLAC:     true,
+		MimeTypeMP3:      true,
 	}
 
 	AllOutputVideoCodecs = map[MimeType]bool{
 		MimeTypeH264: true,
+		MimeTypeH265: true,
+		MimeTypeVP8:  true,
+		MimeTypeVP9:  true,
+		MimeTypeAV1:  true,
 	}
 
+	// in order of preference, with mkv as the fallback
 	AudioOnlyFileOutputTypes = []OutputType{
 		OutputTypeOGG,
 		OutputTypeMP4,
+		OutputTypeWAV,
+		OutputTypeFLAC,
+		OutputTypeMP3,
+		OutputTypeWebM,
+		OutputTypeMKV,
 	}
 	VideoOnlyFileOutputTypes = []OutputType{
 		OutputTypeMP4,
+		OutputTypeWebM,
+		OutputTypeMKV,
 	}
 	AudioVideoFileOutputTypes = []OutputType{
 		OutputTypeMP4,
+		OutputTypeWebM,
+		OutputTypeMKV,
 	}
 
 	TrackOutputTypes = map[MimeType]OutputType{
@@ -61,4 +61,11 @@ func TestGetOutputTypesCompatibleWithCodecs(t *testing.T) {
 	outputTypes = append(outputTypes, OutputTypeMP4)
 	res = GetOutputTypeCompatibleWithCodecs(outputTypes, audioCodecs, videoCodecs)
 	require.Equal(t, OutputTypeMP4, res)
+
+	res = GetOutputTypeCompatibleWithCodecs(AudioVideoFileOutputTypes, map[MimeType]bool{MimeTypeOpus: true}, map[MimeType]bool{MimeTypeVP8: true})
+	require.Equal(t, OutputTypeWebM, res)
+
+	// falls back to mkv
+	res = GetOutputTypeCompatibleWithCodecs(AudioVideoFileOutputTypes, map[MimeType]bool{MimeTypeAAC: true}, map[MimeType]bool{MimeTypeVP8: true})
+	require.Equal(t, OutputTypeMKV, res)
 }
@@ -41,7 +41,7 @@ const (
 )
 
 var (
-	segmentTimeRegexp = regexp.MustCompile(`_(\d{14})(\d{3})\.ts`)
+	segmentTimeRegexp = regexp.MustCompile(`_(\d{14})(\d{3})\.(ts|m4s)`)
 )
 
 type FFProbeInfo struct {
@@ -197,11 +197,23 @@ func verify(t *testing.T, in string, p *config.PipelineConfig, res *livekit.Egre
 
 			case types.MimeTypeRawAudio:
 				require.Equal(t, "pcm_s16le", stream.CodecName)
-				require.Equal(t, "48000", stream.SampleRate)
+				if egressType == types.EgressTypeFile {
+					require.Equal(t, fmt.Sprint(p.AudioFrequency), stream.SampleRate)
+				} else {
+					require.Equal(t, "48000", stream.SampleRate)
+				}
+
+			case types.MimeTypeFLAC:
+				require.Equal(t, "flac", stream.CodecName)
+				require.Equal(t, fmt.Sprint(p.AudioFrequency), stream.SampleRate)
+
+			case types.MimeTypeMP3:
+				require.Equal(t, "mp3", stream.CodecName)
+				require.Equal(t, fmt.Sprint(p.AudioFrequency), stream.SampleRate)
 			}
 
 			// channels
-			require.Equal(t, 2, stream.Channels)
+			require.Equal(t, int(p.AudioChannels), stream.Channels)
 
 			// audio bitrate
 			if p.Outputs[egressType][0].GetOutputType() == types.OutputTypeMP4 {
@@ -228,10 +240,18 @@ func verify(t *testing.T, in string, p *config.PipelineConfig, res *livekit.Egre
 						require.Equal(t, "High", stream.Profile)
 					}
 				}
+			case types.MimeTypeH265:
+				require.Equal(t, "hevc", stream.CodecName)
+
+				if p.VideoEncoding {
+					require.Equal(t, "Main", stream.Profile)
+				}
 			case types.MimeTypeVP8:
 				require.Equal(t, "vp8", stream.CodecName)
 			case types.MimeTypeVP9:
 				require.Equal(t, "vp9", stream.CodecName)
+			case types.MimeTypeAV1:
+				require.Equal(t, "av1", stream.CodecName)
 			}
 
 			switch p.Outputs[egressType][0].GetOutputType() {
@@ -239,7 +259,11 @@ func verify(t *testing.T, in string, p *config.PipelineConfig, res *livekit.Egre
 				require.Equal(t, "vp8", stream.CodecName)
 
 			case types.OutputTypeMP4:
-				require.Equal(t, "h264", stream.CodecName)
+				if p.VideoOutCodec == types.MimeTypeH265 {
+					require.Equal(t, "hevc", stream.CodecName)
+				} else {
+					require.Equal(t, "h264", stream.CodecName)
+				}
 
 				if p.VideoEncoding {
 					// bitrate, not available for HLS or WebM
@@ -262,7 +286,11 @@ func verify(t *testing.T, in string, p *config.PipelineConfig, res *livekit.Egre
 				fallthrough
 
 			case types.OutputTypeHLS:
-				require.Equal(t, "h264", stream.CodecName)
+				if p.VideoOutCodec == types.MimeTypeH265 {
+					require.Equal(t, "hevc", stream.CodecName)
+				} else {
+					require.Equal(t, "h264", stream.CodecName)
+				}
 
 				if p.VideoEncoding {
 					// dimensions
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"strings"
 	"testing"
@@ -70,6 +71,11 @@ type testCase struct {
 	expectVideoEncoding bool
 }
 
+// withOptions adds per-request options to an output location
+func withOptions(location, options string) string {
+	return location + "#lk_egress=" + url.PathEscape(options)
+}
+
 func (r *Runner) RunTests(t *testing.T) {
 	// run tests
 	r.testRoomComposite(t)
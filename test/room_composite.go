@@ -59,6 +59,26 @@ func (r *Runner) testRoomCompositeFile(t *testing.T) {
 			filename:            "r_{room_name}_{time}.mp4",
 			expectVideoEncoding: true,
 		},
+		{
+			name:                "File/WebM",
+			filename:            "r_{room_name}_{time}.webm",
+			expectVideoEncoding: true,
+		},
+		{
+			name:                "File/WebM-VP9",
+			filename:            withOptions("r_{room_name}_vp9_{time}.webm", `{"video_codec":"vp9"}`),
+			expectVideoEncoding: true,
+		},
+		{
+			name:                "File/WebM-AV1",
+			filename:            withOptions("r_{room_name}_av1_{time}.webm", `{"video_codec":"av1"}`),
+			expectVideoEncoding: true,
+		},
+		{
+			name:                "File/WebM-AV1-Av1enc",
+			filename:            withOptions("r_{room_name}_av1enc_{time}.webm", `{"video_codec":"av1","av1_encoder":"av1enc"}`),
+			expectVideoEncoding: true,
+		},
 		{
 			name:      "File/Video-Only",
 			videoOnly: true,
@@ -78,6 +98,12 @@ func (r *Runner) testRoomCompositeFile(t *testing.T) {
 			filename:            "r_{room_name}_audio_{time}",
 			expectVideoEncoding: false,
 		},
+		{
+			name:                "File/Audio-Only-FLAC",
+			audioOnly:           true,
+			filename:            "r_{room_name}_audio_{time}.flac",
+			expectVideoEncoding: false,
+		},
 	} {
 		r.runRoomTest(t, test.name, types.MimeTypeOpus, types.MimeTypeH264, func(t *testing.T) {
 			var fileOutput *livekit.EncodedFileOutput
@@ -98,8 +98,9 @@ func (r *Runner) verifySegmentOutput(t *testing.T, p *config.PipelineConfig, fil
 		if plType == m3u8.PlaylistTypeEvent {
 			// Only download segments once
 			base := storedPlaylistPath[:len(storedPlaylistPath)-5]
+			ext := types.FileExtensionForOutputType[p.GetSegmentOutputType()]
 			for i := 0; i < segmentCount; i++ {
-				cloudPath := fmt.Sprintf("%s_%05d.ts", base, i)
+				cloudPath := fmt.Sprintf("%s_%05d%s", base, i, ext)
 				localPath := path.Join(r.FilePrefix, path.Base(cloudPath))
 				download(t, uploadConfig, localPath, cloudPath)
 			}
@@ -62,6 +62,13 @@ func (r *Runner) testTrackFile(t *testing.T) {
 			outputType: types.OutputTypeOGG,
 			filename:   "t_{track_source}_{time}.ogg",
 		},
+		{
+			name:       "File/OPUS-WAV",
+			audioOnly:  true,
+			audioCodec: types.MimeTypeOpus,
+			outputType: types.OutputTypeWAV,
+			filename:   "t_{track_source}_{time}.wav",
+		},
 		{
 			name:       "File/H264",
 			videoOnly:  true,
@@ -76,6 +83,13 @@ func (r *Runner) testTrackFile(t *testing.T) {
 			outputType: types.OutputTypeWebM,
 			filename:   "t_{track_type}_{time}.webm",
 		},
+		{
+			name:       "File/H264-MKV",
+			videoOnly:  true,
+			videoCodec: types.MimeTypeH264,
+			outputType: types.OutputTypeMKV,
+			filename:   "t_{track_id}_{time}.mkv",
+		},
 		// {
 		// 	name:       "File/VP9",
 		// 	videoOnly:  true,
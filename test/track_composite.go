@@ -64,6 +64,13 @@ func (r *Runner) testTrackCompositeFile(t *testing.T) {
 			videoCodec: types.MimeTypeVP8,
 			filename:   "tc_{publisher_identity}_vp8_{time}.mp4",
 		},
+		{
+			name:       "File/H265",
+			fileType:   livekit.EncodedFileType_MP4,
+			audioCodec: types.MimeTypeOpus,
+			videoCodec: types.MimeTypeVP8,
+			filename:   withOptions("tc_{publisher_identity}_h265_{time}.mp4", `{"video_codec":"h265"}`),
+		},
 		{
 			name:       "File/VideoOnly",
 			fileType:   livekit.EncodedFileType_MP4,
@@ -168,6 +175,14 @@ func (r *Runner) testTrackCompositeSegments(t *testing.T) {
 			playlist:     "tcs_{room_name}_h264_{time}.m3u8",
 			livePlaylist: "tcs_live_{room_name}_h264_{time}.m3u8",
 		},
+		{
+			name:         "Segments/H265",
+			audioCodec:   types.MimeTypeOpus,
+			videoCodec:   types.MimeTypeVP8,
+			filename:     withOptions("tcs_{room_name}_h265_{time}", `{"video_codec":"h265"}`),
+			playlist:     "tcs_{room_name}_h265_{time}.m3u8",
+			livePlaylist: "tcs_live_{room_name}_h265_{time}.m3u8",
+		},
 		{
 			name:       "Segments/Audio-Only",
 			audioCodec: types.MimeTypeOpus,